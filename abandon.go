@@ -0,0 +1,92 @@
+package godag
+
+import (
+	"sort"
+	"time"
+)
+
+// AbandonedOp is an op invocation which was abandoned by the run (because of
+// timeout or cancellation) but has not returned yet. An op honoring its context
+// should return soon after abandoned, the ones which do not are leaking goroutines.
+type AbandonedOp struct {
+	ID          string    // node id
+	Attempt     int       // attempt number, start from 1, 0 is the fallback op
+	StartTime   time.Time // when the op started
+	AbandonTime time.Time // when the run stopped waiting for the op
+}
+
+// invocation tracks an op invocation of a run, guarded by DAG.mu
+type invocation struct {
+	op        AbandonedOp
+	idx       int
+	state     *nodeState // state of the node in the run which started the op
+	returned  bool       // the op returned
+	abandoned bool       // the run stopped waiting for the op
+}
+
+// WithAbandonReport calls report for every op which is still running after
+// abandoned for grace, so that ops ignoring their context can be alerted
+func (p *DAG) WithAbandonReport(grace time.Duration, report func(op AbandonedOp)) *DAG {
+	p.abandonGrace = grace
+	p.onAbandoned = report
+	return p
+}
+
+// GetAbandonedOps returns the ops abandoned by the run which have not returned yet
+func (p *DAG) GetAbandonedOps() []AbandonedOp {
+	p.mu.Lock()
+	ops := make([]AbandonedOp, 0, len(p.abandoned))
+	for inv := range p.abandoned {
+		ops = append(ops, inv.op)
+	}
+	p.mu.Unlock()
+	sort.Slice(ops, func(i, j int) bool {
+		return ops[i].AbandonTime.Before(ops[j].AbandonTime)
+	})
+	return ops
+}
+
+// opReturned is called when the op of inv returned, whether abandoned or not,
+// the resources of a node finished are released once its last op returned
+func (p *DAG) opReturned(inv *invocation) {
+	p.mu.Lock()
+	inv.returned = true
+	if inv.abandoned {
+		delete(p.abandoned, inv)
+	}
+	state := inv.state
+	state.running--
+	release := state.running == 0 && state.held
+	state.held = false
+	p.mu.Unlock()
+	if release {
+		p.resources.release(p.tpl.nodes[inv.idx].resources)
+	}
+}
+
+// abandon registers inv as abandoned unless the op already returned. The
+// result of an abandoned op is dropped, it never reaches the state keeper.
+func (p *DAG) abandon(inv *invocation) {
+	p.mu.Lock()
+	if inv.returned {
+		p.mu.Unlock()
+		return
+	}
+	inv.abandoned = true
+	inv.op.AbandonTime = time.Now()
+	p.abandoned[inv] = struct{}{}
+	grace, report := p.abandonGrace, p.onAbandoned
+	p.mu.Unlock()
+
+	if report == nil {
+		return
+	}
+	time.AfterFunc(grace, func() {
+		p.mu.Lock()
+		_, running := p.abandoned[inv]
+		p.mu.Unlock()
+		if running {
+			report(inv.op)
+		}
+	})
+}
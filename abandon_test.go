@@ -0,0 +1,54 @@
+package godag
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// CtxOp returns when ctx is done
+type CtxOp struct{}
+
+func (o *CtxOp) ProcessE(ctx context.Context, global interface{}, input ...interface{}) (interface{}, error) {
+	<-ctx.Done()
+	return "late", ctx.Err()
+}
+
+func TestAbandonedOps(t *testing.T) {
+	start := NewStartNode("start")
+	// op1 ignores ctx, op2 honors it
+	start.AddNext("op1", &SimpleOp{data: "op1_data", processTime: 200 * time.Millisecond}).WithTimeout(10 * time.Millisecond)
+	start.AddNextE("op2", &CtxOp{}).WithTimeout(10 * time.Millisecond)
+
+	reported := make(chan AbandonedOp, 2)
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	dag.WithAbandonReport(50*time.Millisecond, func(op AbandonedOp) {
+		reported <- op
+	})
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, StatusTimeout, dag.GetNodeStatus("op1"))
+	assert.Equal(t, StatusTimeout, dag.GetNodeStatus("op2"))
+
+	time.Sleep(10 * time.Millisecond) // op2 returns soon after abandoned
+	ops := dag.GetAbandonedOps()
+	assert.Equal(t, 1, len(ops))
+	assert.Equal(t, "op1", ops[0].ID)
+	assert.Equal(t, 1, ops[0].Attempt)
+	assert.True(t, ops[0].AbandonTime.Sub(ops[0].StartTime) >= 10*time.Millisecond)
+
+	select {
+	case op := <-reported:
+		assert.Equal(t, "op1", op.ID)
+		assert.True(t, time.Since(op.AbandonTime) >= 50*time.Millisecond)
+	case <-time.After(time.Second):
+		assert.Fail(t, "abandoned op1 not reported")
+	}
+
+	time.Sleep(250 * time.Millisecond) // op1 returns at last
+	assert.Empty(t, dag.GetAbandonedOps())
+	assert.Empty(t, reported)
+	assert.Empty(t, dag.GetStateKeeper().GetAllOutput()) // late results are dropped
+}
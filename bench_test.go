@@ -0,0 +1,190 @@
+package godag
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// NopOp returns its first input, it costs nothing compared with the scheduling
+type NopOp struct{}
+
+func (o NopOp) Process(ctx context.Context, global interface{}, input ...interface{}) interface{} {
+	if len(input) > 0 {
+		return input[0]
+	}
+	return nil
+}
+
+// buildChain builds start -> op0 -> op1 -> ... -> op(n-1)
+func buildChain(n int) *Node {
+	start := NewStartNode("start")
+	node := start
+	for i := 0; i < n; i++ {
+		node = node.AddNext(fmt.Sprintf("op%d", i), NopOp{})
+	}
+	return start
+}
+
+// buildWide builds start -> op0 ... op(n-1)
+func buildWide(n int) *Node {
+	start := NewStartNode("start")
+	for i := 0; i < n; i++ {
+		start.AddNext(fmt.Sprintf("op%d", i), NopOp{})
+	}
+	return start
+}
+
+// buildDiamonds builds n diamonds in a chain: fork -> left, right -> join -> ...
+func buildDiamonds(n int) *Node {
+	start := NewStartNode("start")
+	node := start
+	for i := 0; i < n; i++ {
+		left := node.AddNext(fmt.Sprintf("left%d", i), NopOp{})
+		right := node.AddNext(fmt.Sprintf("right%d", i), NopOp{})
+		node = left.AddNext(fmt.Sprintf("join%d", i), NopOp{})
+		right.AddNextNode(node)
+	}
+	return start
+}
+
+func benchmarkRun(b *testing.B, start *Node, autoInline bool) {
+	tpl, err := Compile(start)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := tpl.NewDAG(nil).WithAutoInline(autoInline).Execute(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkSchedulers runs the graph from start by the channel-based
+// scheduler and by the current one, compare them by
+//
+//	go test -run '^$' -bench 'Chain$|Wide$|Diamond$' -benchmem
+func benchmarkSchedulers(b *testing.B, start *Node) {
+	b.Run("channel", func(b *testing.B) {
+		tpl, err := Compile(start)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			newChannelRun(tpl).execute(context.Background())
+		}
+	})
+	b.Run("atomic", func(b *testing.B) {
+		benchmarkRun(b, start, false)
+	})
+}
+
+// channelRun is a run by the channel-based scheduler of the first godag
+// (commit e2c03cf), kept as the baseline of the benchmarks: a dispatch loop
+// receives the ready nodes from an unbuffered channel, each node costs a
+// goroutine waiting for another one running its op and a third one releasing
+// its children, the indegrees and the active nodes are counted under a mutex.
+// It supports plain Op without timeout only and keeps no status, error or
+// report of the nodes, which the current scheduler pays for as well.
+type channelRun struct {
+	tpl         *Template
+	stateKeeper StateKeeper
+	mu          sync.Mutex
+	indegree    []int
+	activeNum   int
+	taskChan    chan int
+	doneChan    chan struct{}
+}
+
+func newChannelRun(tpl *Template) *channelRun {
+	r := &channelRun{
+		tpl:         tpl,
+		stateKeeper: NewDefaultStateKeeper(),
+		indegree:    make([]int, len(tpl.nodes)),
+		activeNum:   1,
+		taskChan:    make(chan int),
+		doneChan:    make(chan struct{}),
+	}
+	copy(r.indegree, tpl.indegree)
+	return r
+}
+
+func (r *channelRun) execute(ctx context.Context) {
+	Go(func() {
+		r.taskChan <- 0
+	})
+	for {
+		select {
+		case idx := <-r.taskChan:
+			Go(func() {
+				r.processNode(ctx, idx)
+			})
+		case <-r.doneChan:
+			return
+		}
+	}
+}
+
+func (r *channelRun) processNode(ctx context.Context, idx int) {
+	node := r.tpl.nodes[idx]
+	doneChan := make(chan struct{})
+	Go(func() {
+		if node.op != nil {
+			prev := r.tpl.prev[idx]
+			args := make([]interface{}, len(prev))
+			for i, parent := range prev {
+				args[i] = r.stateKeeper.GetInput(r.tpl.nodes[parent].id, node.id)
+			}
+			opCtx := context.WithValue(ctx, StateKey(NodeID), node.id)
+			r.stateKeeper.SetOutput(node.id, node.op.Process(opCtx, r.stateKeeper.GetGlobal(), args...))
+		}
+		close(doneChan)
+	})
+	<-doneChan
+	Go(func() {
+		for _, next := range r.tpl.next[idx] {
+			r.mu.Lock()
+			r.indegree[next]--
+			ready := r.indegree[next] == 0
+			if ready {
+				r.activeNum++ // before the node is sent
+			}
+			r.mu.Unlock()
+			if ready {
+				r.taskChan <- next
+			}
+		}
+		r.mu.Lock()
+		r.activeNum--
+		done := r.activeNum == 0
+		r.mu.Unlock()
+		if done {
+			close(r.doneChan)
+		}
+	})
+}
+
+func BenchmarkChain(b *testing.B) {
+	benchmarkSchedulers(b, buildChain(100))
+}
+
+func BenchmarkChainAutoInline(b *testing.B) {
+	benchmarkRun(b, buildChain(100), true)
+}
+
+func BenchmarkWide(b *testing.B) {
+	benchmarkSchedulers(b, buildWide(100))
+}
+
+func BenchmarkDiamond(b *testing.B) {
+	benchmarkSchedulers(b, buildDiamonds(33))
+}
+
+func BenchmarkDiamondAutoInline(b *testing.B) {
+	benchmarkRun(b, buildDiamonds(33), true)
+}
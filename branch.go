@@ -0,0 +1,111 @@
+package godag
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// ErrBranchNotTaken is the error of a node skipped because its condition is
+// false, a switch did not select it or all its parents were skipped so
+var ErrBranchNotTaken = errors.New("godag: branch not taken")
+
+// Condition decides whether a node runs, it is evaluated against the global
+// state and the inputs of the node when the node is about to run
+type Condition func(global interface{}, in Inputs) bool
+
+// WithCondition runs the op of n only if cond returns true. Otherwise n is
+// StatusSkipped with ErrBranchNotTaken, and so are its descendants whose
+// parents are all skipped that way, while a join node with other parents
+// still runs, getting nil as the input from n. A panic in cond fails n.
+func (n *Node) WithCondition(cond Condition) *Node {
+	n.condition = cond
+	return n
+}
+
+// WithSwitch makes n a switch node: the output of its op is the id (string)
+// or ids ([]string) of the children to run, nil for none. The children not
+// selected are skipped as if their condition were false (see WithCondition),
+// an id which is not a child fails n.
+func (n *Node) WithSwitch() *Node {
+	n.switcher = true
+	return n
+}
+
+// checkCondition evaluates the condition of node idx
+func (p *DAG) checkCondition(idx int, global interface{}, in Inputs) (ok bool, err error) {
+	cond := p.tpl.nodes[idx].condition
+	if cond == nil {
+		return true, nil
+	}
+	defer func() {
+		if v := recover(); v != nil {
+			ok, err = false, &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return cond(global, in), nil
+}
+
+// selectChildren returns the children of the switch node idx selected by output
+func (p *DAG) selectChildren(idx int, output interface{}) ([]int, error) {
+	var ids []string
+	switch v := output.(type) {
+	case nil:
+	case string:
+		ids = []string{v}
+	case []string:
+		ids = v
+	default:
+		return nil, fmt.Errorf("godag: switch %q returned %T, want string or []string", p.tpl.nodes[idx].id, output)
+	}
+	selected := make([]int, 0, len(ids))
+	for _, id := range ids {
+		child, ok := p.tpl.index[id]
+		if !ok || !containsInt(p.tpl.next[idx], child) {
+			return nil, fmt.Errorf("godag: switch %q selected %q which is not its child", p.tpl.nodes[idx].id, id)
+		}
+		selected = append(selected, child)
+	}
+	return selected, nil
+}
+
+// pruneBranch marks node idx skipped by ErrBranchNotTaken if no branch leads to it
+func (p *DAG) pruneBranch(idx int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.branchNotTaken(idx) {
+		return false
+	}
+	p.states[idx].err = ErrBranchNotTaken
+	p.states[idx].pruned = true
+	return true
+}
+
+// branchNotTaken reports whether no branch leads to node idx: every parent
+// is skipped by ErrBranchNotTaken or is a switch not selecting idx. Guarded by p.mu.
+func (p *DAG) branchNotTaken(idx int) bool {
+	prev := p.tpl.prev[idx]
+	if len(prev) == 0 {
+		return false
+	}
+	for _, parent := range prev {
+		state := &p.states[parent]
+		if state.pruned {
+			continue
+		}
+		if state.switched && !containsInt(state.selected, idx) {
+			continue
+		}
+		return false
+	}
+	return true
+}
+
+func containsInt(s []int, v int) bool {
+	for _, i := range s {
+		if i == v {
+			return true
+		}
+	}
+	return false
+}
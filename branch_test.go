@@ -0,0 +1,103 @@
+package godag
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ValueOp returns value
+type ValueOp struct {
+	value interface{}
+}
+
+func (o *ValueOp) Process(ctx context.Context, global interface{}, input ...interface{}) interface{} {
+	return o.value
+}
+
+func TestCondition(t *testing.T) {
+	/**
+	           |-> history -> fe_pvreal2(if history) -> fe_child -> |
+	    start->|                    |----------------------------> |-> join
+	           |-> fe_base ----------------------------------------> |
+	**/
+	hasHistory := func(global interface{}, in Inputs) bool {
+		return global.(map[string]bool)["history"] && in.At(0) != ""
+	}
+	start := NewStartNode("start")
+	history := start.AddNext("history", &SimpleOp{data: "history_data"})
+	fePvreal2 := history.AddNext("fe_pvreal2", &ConcatOp{name: "fe_pvreal2"}).WithCondition(hasHistory)
+	feChild := fePvreal2.AddNext("fe_child", &ConcatOp{name: "fe_child"})
+	join := feChild.AddNext("join", &ConcatOp{name: "join"})
+	fePvreal2.AddNextNode(join)
+	start.AddNext("fe_base", &ConcatOp{name: "fe_base"}).AddNextNode(join)
+
+	tpl, err := Compile(start)
+	assert.NoError(t, err)
+	for _, withHistory := range []bool{true, false} {
+		sk := NewDefaultStateKeeper()
+		sk.SetGlobal(map[string]bool{"history": withHistory})
+		dag := tpl.NewDAG(sk)
+		assert.NoError(t, dag.Execute(context.Background()))
+		assert.Equal(t, StatusSuccess, dag.GetNodeStatus("join"))
+		if withHistory {
+			assert.Equal(t, StatusSuccess, dag.GetNodeStatus("fe_pvreal2"))
+			assert.Equal(t, StatusSuccess, dag.GetNodeStatus("fe_child"))
+			continue
+		}
+		assert.Equal(t, StatusSkipped, dag.GetNodeStatus("fe_pvreal2"))
+		assert.Equal(t, StatusSkipped, dag.GetNodeStatus("fe_child"))
+		assert.Equal(t, ErrBranchNotTaken.Error(), dag.GetReport().Node("fe_child").Error)
+		assert.Equal(t, "map[history:false]join[<nil> <nil> map[history:false]fe_base[<nil>]]", sk.GetOutput("join"))
+	}
+}
+
+func TestSwitch(t *testing.T) {
+	/**
+	                   |-> a -> a2 ->|
+	    start -> sw -> |             |-> join
+	                   |-> b ------->|
+	                   |-> c
+	**/
+	build := func(selected interface{}) *Node {
+		start := NewStartNode("start")
+		sw := start.AddNext("sw", &ValueOp{value: selected}).WithSwitch()
+		join := sw.AddNext("a", &ConcatOp{name: "a"}).AddNext("a2", &ConcatOp{name: "a2"}).
+			AddNext("join", &ConcatOp{name: "join"})
+		sw.AddNext("b", &ConcatOp{name: "b"}).AddNextNode(join)
+		sw.AddNext("c", &ConcatOp{name: "c"})
+		return start
+	}
+
+	var dag DAG
+	assert.NoError(t, dag.Init(build("b"), nil))
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, map[string]NodeStatus{
+		"start": StatusSuccess,
+		"sw":    StatusSuccess,
+		"a":     StatusSkipped,
+		"a2":    StatusSkipped,
+		"b":     StatusSuccess,
+		"c":     StatusSkipped,
+		"join":  StatusSuccess,
+	}, dag.GetAllNodeStatus())
+	assert.Equal(t, "<nil>join[<nil> <nil>b[b]]", dag.GetStateKeeper().GetOutput("join"))
+
+	assert.NoError(t, dag.Init(build([]string{"a", "c"}), nil))
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("b"))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("a2"))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("c"))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("join"))
+
+	assert.NoError(t, dag.Init(build(nil), nil))
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("join"))
+
+	assert.NoError(t, dag.Init(build("start"), nil))
+	assert.Error(t, dag.Execute(context.Background()))
+	assert.Equal(t, StatusFailed, dag.GetNodeStatus("sw"))
+	assert.Equal(t, `godag: switch "sw" selected "start" which is not its child`, dag.GetReport().Node("sw").Error)
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("join"))
+}
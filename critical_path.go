@@ -0,0 +1,134 @@
+package godag
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// CriticalPath is the chain of nodes which determined the latency of a run.
+//
+// The time of a node is its queue wait, resource wait and duration, the earliest finish
+// of a node is the max earliest finish of its parents plus its own time. The
+// critical path ends at the node with the latest earliest finish and goes back
+// through the parent finishing last, the slack of a node is how much its time
+// could grow without making the critical path longer.
+type CriticalPath struct {
+	Path  []string       // id of the nodes from the start node to the last sink
+	Total time.Duration  // length of the critical path
+	Nodes []PathNodeInfo // every node of the run, in the order of Report.Nodes
+}
+
+// PathNodeInfo is the analysis of a node in the critical path
+type PathNodeInfo struct {
+	ID             string
+	Critical       bool          // on the critical path
+	Time           time.Duration // queue wait, resource wait and duration
+	EarliestFinish time.Duration // from the start of the run
+	Slack          time.Duration // 0 for the nodes on the critical path
+	Share          float64       // Time / Total
+}
+
+// CriticalPath analyzes the critical path of the run
+func (r *Report) CriticalPath() *CriticalPath {
+	n := len(r.Nodes)
+	index := make(map[string]int, n)
+	for i := range r.Nodes {
+		index[r.Nodes[i].ID] = i
+	}
+	children := make([][]int, n)
+	indegree := make([]int, n)
+	for i := range r.Nodes {
+		for _, parent := range r.Nodes[i].Prev {
+			children[index[parent]] = append(children[index[parent]], i)
+			indegree[i]++
+		}
+	}
+	// topological order
+	order := make([]int, 0, n)
+	for i := range indegree {
+		if indegree[i] == 0 {
+			order = append(order, i)
+		}
+	}
+	for i := 0; i < len(order); i++ {
+		for _, child := range children[order[i]] {
+			indegree[child]--
+			if indegree[child] == 0 {
+				order = append(order, child)
+			}
+		}
+	}
+
+	c := &CriticalPath{Nodes: make([]PathNodeInfo, n)}
+	finish := make([]time.Duration, n)
+	last := -1
+	for _, i := range order {
+		info := &c.Nodes[i]
+		info.ID = r.Nodes[i].ID
+		info.Time = r.Nodes[i].QueueWait + r.Nodes[i].ResourceWait + r.Nodes[i].Duration
+		var begin time.Duration
+		for _, parent := range r.Nodes[i].Prev {
+			if f := finish[index[parent]]; f > begin {
+				begin = f
+			}
+		}
+		finish[i] = begin + info.Time
+		info.EarliestFinish = finish[i]
+		if last < 0 || finish[i] > finish[last] {
+			last = i
+		}
+	}
+	if last < 0 {
+		return c
+	}
+	c.Total = finish[last]
+
+	// latest finish without making the critical path longer, in reverse topological order
+	latest := make([]time.Duration, n)
+	for k := len(order) - 1; k >= 0; k-- {
+		i := order[k]
+		latest[i] = c.Total
+		for _, child := range children[i] {
+			if l := latest[child] - c.Nodes[child].Time; l < latest[i] {
+				latest[i] = l
+			}
+		}
+		c.Nodes[i].Slack = latest[i] - finish[i]
+		if c.Total > 0 {
+			c.Nodes[i].Share = float64(c.Nodes[i].Time) / float64(c.Total)
+		}
+	}
+
+	for i := last; i >= 0; {
+		c.Nodes[i].Critical = true
+		c.Path = append([]string{c.Nodes[i].ID}, c.Path...)
+		next := -1
+		for _, parent := range r.Nodes[i].Prev {
+			p := index[parent]
+			if next < 0 || finish[p] > finish[next] {
+				next = p
+			}
+		}
+		i = next
+	}
+	return c
+}
+
+// String renders the critical path and a table of the nodes
+func (c *CriticalPath) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "critical path %v: %s\n", c.Total, strings.Join(c.Path, " -> "))
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "node\ttime\tfinish\tslack\tshare\tcritical")
+	for _, info := range c.Nodes {
+		critical := ""
+		if info.Critical {
+			critical = "*"
+		}
+		fmt.Fprintf(w, "%s\t%v\t%v\t%v\t%.1f%%\t%s\n", info.ID, info.Time, info.EarliestFinish, info.Slack, info.Share*100, critical)
+	}
+	w.Flush()
+	return b.String()
+}
@@ -0,0 +1,63 @@
+package godag
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCriticalPath(t *testing.T) {
+	/**
+	           |-> op1(1s) -> op3(3s) -> |
+	    start->|                         |-> op4(4s)
+	           |-> op2(2s) ------------> |
+	**/
+	report := &Report{Nodes: []NodeReport{
+		{ID: "start"},
+		{ID: "op1", Prev: []string{"start"}, Duration: time.Second},
+		{ID: "op2", Prev: []string{"start"}, Duration: 2 * time.Second},
+		{ID: "op4", Prev: []string{"op3", "op2"}, QueueWait: time.Second, Duration: 3 * time.Second},
+		{ID: "op3", Prev: []string{"op1"}, Duration: 3 * time.Second},
+	}}
+	c := report.CriticalPath()
+	assert.Equal(t, []string{"start", "op1", "op3", "op4"}, c.Path)
+	assert.Equal(t, 8*time.Second, c.Total)
+
+	op2 := c.Nodes[2]
+	assert.False(t, op2.Critical)
+	assert.Equal(t, 2*time.Second, op2.EarliestFinish)
+	assert.Equal(t, 2*time.Second, op2.Slack)
+	op4 := c.Nodes[3]
+	assert.True(t, op4.Critical)
+	assert.Equal(t, 4*time.Second, op4.Time)
+	assert.Equal(t, time.Duration(0), op4.Slack)
+	assert.Equal(t, 0.5, op4.Share)
+
+	assert.Equal(t, `critical path 8s: start -> op1 -> op3 -> op4
+node   time  finish  slack  share  critical
+start  0s    0s      0s     0.0%   *
+op1    1s    1s      0s     12.5%  *
+op2    2s    2s      2s     25.0%  
+op4    4s    8s      0s     50.0%  *
+op3    3s    4s      0s     37.5%  *
+`, c.String())
+}
+
+func TestCriticalPathOfRun(t *testing.T) {
+	start := NewStartNode("start")
+	op1 := start.AddNext("op1", &SimpleOp{data: "op1_data", processTime: 10 * time.Millisecond})
+	op2 := start.AddNext("op2", &SimpleOp{data: "op2_data", processTime: 60 * time.Millisecond})
+	op3 := op1.AddNext("op3", &SimpleOp{data: "op3_data", processTime: 10 * time.Millisecond})
+	op2.AddNextNode(op3)
+
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	assert.NoError(t, dag.Execute(context.Background()))
+	c := dag.GetReport().CriticalPath()
+	fmt.Print(c)
+	assert.Equal(t, []string{"start", "op2", "op3"}, c.Path)
+	assert.True(t, c.Nodes[1].Slack >= 40*time.Millisecond) // op1
+}
@@ -3,11 +3,13 @@ package godag
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 	// "fmt"
 )
 
-// default go impl, can replace by other go routine pool impl
+// default go impl used by GoExecutor, DAG.WithExecutor sets the executor of a
+// single run rather than every DAG in the process
 var Go = func (f func()) {
 	go f()
 }
@@ -18,114 +20,601 @@ type Op interface {
 	Process(ctx context.Context, global interface{}, input ...interface{}) interface{} // pass input with the order of prev
 }
 
+// OpE is an Op which can fail. If the returned error is not nil, the node is
+// recorded as failed and its children will not run.
+type OpE interface {
+	ProcessE(ctx context.Context, global interface{}, input ...interface{}) (interface{}, error)
+}
+
 type StateKey string
 const NodeID = "__nodeID__"
 
+// DAG is one run of a Template
 type DAG struct {
-	startNode   *Node
-	mu          sync.Mutex
-	activeNum   int
-	taskChan    chan *Node
-	doneChan    chan struct{}
-	stateKeeper StateKeeper
+	tpl          *Template
+	states       []nodeState // run state of each node, indexed as tpl.nodes
+	mu           sync.Mutex
+	activeNum    int32 // nodes ready but not finished, accessed atomically
+	doneChan     chan struct{}
+	stateKeeper  StateKeeper
+	errs         []NodeError
+	policy       FailurePolicy
+	aborted      bool                     // set by FailFast once a node failed
+	cancel       context.CancelFunc       // cancel the context shared by the run
+	startTime    time.Time                // when Execute started
+	endTime      time.Time                // when Execute returned
+	abandoned    map[*invocation]struct{} // ops abandoned but not returned yet
+	abandonGrace time.Duration
+	onAbandoned  func(op AbandonedOp)
+	executor     Executor        // set by WithExecutor, nil means GoExecutor
+	resources    *Resources      // set by WithResources
+	schedule     SchedulePolicy  // set by WithSchedulePolicy
+	autoInline   bool            // set by WithAutoInline
+	priorities   []int64         // priority of each node, nil if not prioritized
+	deadline     time.Time       // set by WithDeadline
+	timeout      time.Duration   // set by WithTimeout
+	budget       bool            // set by WithDeadlineBudget
+	runDeadline  time.Time       // deadline of the run, zero if none
+	overrun      bool            // a node was skipped or timed out by runDeadline
+	estimates    []time.Duration // estimate of each node when the run started
+	tails        []time.Duration // estimate of the longest path from each node
+
+	transformed map[transformKey]*transformResult // outputs transformed by the edges
+}
+
+// nodeState is the state of a node in one run
+type nodeState struct {
+	indegree     int32 // parents not finished, accessed atomically
+	status       NodeStatus
+	err          error // error returned by OpE
+	fallback     bool  // the fallback is used as the output
+	attempts     int
+	readyTime    time.Time     // when all the parents finished
+	resourceWait time.Duration // waiting for the resources of the node
+	inline       bool          // the op runs on the goroutine which made the node ready
+	pruned       bool          // skipped by ErrBranchNotTaken
+	switched     bool          // the switch node selected its children
+	fired        int32         // the node is made ready, accessed atomically, see TriggerFirstSuccess
+	lost         bool          // canceled by a sibling, see WithFirstSuccess
+	returned     bool          // the outcome of the op is known, set by completeNode
+	finished     bool          // the status and the output are final, set by finishNode
+	selected     []int         // children selected by the switch node
+	startTime    time.Time     // when the op started
+	endTime      time.Time     // when the op finished
+
+	cancel    context.CancelFunc // cancel the op, set if a sibling can cancel it
+	instances []instanceState    // instances of the map node
+	running   int                // invocations of the op not returned
+	held      bool               // the resources are released once running is 0
+}
+
+// costTime returns how long the op ran
+func (s *nodeState) costTime() time.Duration {
+	if s.startTime.IsZero() || s.endTime.IsZero() {
+		return 0
+	}
+	return s.endTime.Sub(s.startTime)
 }
 
-func (p *DAG) Init(startNode *Node, stateKeeper StateKeeper) bool {
-	p.startNode = startNode
+// Init validates and compiles the graph from startNode and prepares a run of it,
+// use Compile and Template.NewDAG to execute the same graph many times
+func (p *DAG) Init(startNode *Node, stateKeeper StateKeeper) error {
+	tpl, err := Compile(startNode)
+	if err != nil {
+		return err
+	}
+	p.init(tpl, stateKeeper)
+	return nil
+}
+
+func (p *DAG) init(tpl *Template, stateKeeper StateKeeper) {
+	p.tpl = tpl
 	if stateKeeper == nil {
 		p.stateKeeper = NewDefaultStateKeeper()
 	} else {
 		p.stateKeeper = stateKeeper
 	}
+	p.states = make([]nodeState, len(tpl.nodes))
+	for i := range p.states {
+		p.states[i].indegree = int32(tpl.indegree[i])
+	}
 	p.activeNum = 1
-	p.taskChan = make(chan *Node)
+	p.errs = nil
+	p.aborted = false
+	p.abandoned = make(map[*invocation]struct{})
+	p.runDeadline = time.Time{}
+	p.overrun = false
+	p.estimates, p.tails = nil, nil
+	p.transformed = nil
 	p.doneChan = make(chan struct{})
-	Go(func() {
-		p.taskChan <- startNode
-	})
-	return true
 }
 
-func (p *DAG) Execute(ctx context.Context) {
-	for {
-		select {
-		case node := <-p.taskChan:
-			Go(func (){
-				p.processNode(ctx, node)
-			})
-		case <-p.doneChan:
-			return
-		}
-	}
+// WithFailurePolicy sets the policy applied when a node fails, default is SkipDescendants
+func (p *DAG) WithFailurePolicy(policy FailurePolicy) *DAG {
+	p.policy = policy
+	return p
 }
 
-func (p *DAG) processNode(ctx context.Context, node *Node) {
+// Execute runs the DAG until every node is processed. If any op failed or ctx
+// is done before the end, a *RunError is returned.
+//
+// Once ctx is done no more op is started, the running ops see the cancellation
+// from their context and are abandoned, the nodes never started are left as
+// StatusNotStarted, see GetRunStatus.
+func (p *DAG) Execute(ctx context.Context) error {
 	if ctx == nil {
 		ctx = context.Background()
 	}
-	
-	if node.timeout > 0 {
-		// fmt.Println("node timeout = ", node.id, node.timeout)
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, node.timeout)
-		defer cancel()
+	parent := ctx
+	ctx, p.cancel = context.WithCancel(ctx)
+	defer p.cancel()
+	p.mu.Lock()
+	p.startTime = time.Now()
+	p.states[0].readyTime = p.startTime
+	p.mu.Unlock()
+	p.initDeadline(p.startTime)
+	p.initPriorities()
+	defer func() {
+		p.mu.Lock()
+		p.endTime = time.Now()
+		p.mu.Unlock()
+	}()
+	p.dispatch(ctx, []int{0}) // the start node, the others are dispatched once their parents finished
+	<-p.doneChan
+	if err := parent.Err(); err != nil {
+		status := p.GetRunStatus()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return &RunError{Nodes: p.errs, Cause: err, Status: &status}
 	}
+	p.mu.Lock()
+	overrun := p.overrun
+	p.mu.Unlock()
+	if overrun {
+		status := p.GetRunStatus()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return &RunError{Nodes: p.errs, Cause: ErrRunDeadline, Status: &status}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.errs) > 0 {
+		return &RunError{Nodes: p.errs}
+	}
+	return nil
+}
 
-	doneChan := make(chan struct{})
+// dispatch processes the ready nodes on the calling goroutine, and the nodes
+// they make ready in turn, until every op left is submitted to the executor.
+// This is the only loop of the scheduler: a node costs no goroutine but the
+// one running its op, and the continuation of the op runs on that goroutine.
+func (p *DAG) dispatch(ctx context.Context, ready []int) {
+	for i := 0; i < len(ready); i++ {
+		ready = append(ready, p.processNode(ctx, ready[i])...)
+	}
+}
+
+// processNode runs the op of node idx once its resources are taken. The
+// nodes made ready without running an op (e.g. the node is skipped) are
+// returned to the caller rather than processed recursively.
+func (p *DAG) processNode(ctx context.Context, idx int) []int {
+	if p.pruneBranch(idx) {
+		return p.finishNode(ctx, idx, StatusSkipped)
+	}
+	if p.shouldSkip(idx) {
+		return p.finishNode(ctx, idx, StatusSkipped)
+	}
+	if ctx.Err() != nil { // the run is canceled, do not start any more op
+		return p.finishNode(ctx, idx, StatusNotStarted)
+	}
+	return p.acquireResources(ctx, idx, func() []int {
+		return p.startNode(ctx, idx)
+	})
+}
+
+// startNode submits the op of node idx, its resources are taken
+func (p *DAG) startNode(ctx context.Context, idx int) []int {
+	node := p.tpl.nodes[idx]
+	if ctx.Err() != nil { // canceled while waiting for the resources
+		p.releaseResources(idx)
+		return p.finishNode(ctx, idx, StatusNotStarted)
+	}
 	startTime := time.Now()
-	Go(func() {
-		if node.op != nil {
-			args := make([]interface{}, len(node.prev))
-			for idx := range node.prev {
-				// NOTE: the order of prev will result the order of args passed to op
-				args[idx] = p.stateKeeper.GetInput(node.prev[idx].id, node.id) // will get the parent output as input of current
-			}
-			ctx = context.WithValue(ctx, StateKey(NodeID), node.id)
-			global := p.stateKeeper.GetGlobal()
-			output := node.op.Process(ctx, global, args...)
-			if !node.isCanceled { // if timeout, no need to save output
-				p.stateKeeper.SetOutput(node.id, output)
-			}
+	if !p.runDeadline.IsZero() && !startTime.Before(p.runDeadline) {
+		p.releaseResources(idx)
+		p.mu.Lock()
+		p.states[idx].err = ErrRunDeadline
+		p.overrun = true
+		p.mu.Unlock()
+		return p.finishNode(ctx, idx, StatusSkipped)
+	}
+
+	if node.op == nil {
+		p.mu.Lock()
+		p.states[idx].startTime = startTime
+		p.mu.Unlock()
+		return p.completeNode(ctx, idx, nil, Inputs{}, nil, nil)
+	}
+	global := p.stateKeeper.GetGlobal()
+	in, err := p.readInputs(idx)
+	run := false
+	if err == nil {
+		run, err = p.checkCondition(idx, global, in)
+	}
+	if err != nil {
+		p.mu.Lock()
+		p.states[idx].startTime = startTime
+		p.mu.Unlock()
+		return p.completeNode(ctx, idx, global, in, nil, err)
+	}
+	if !run {
+		p.releaseResources(idx)
+		p.mu.Lock()
+		p.states[idx].err = ErrBranchNotTaken
+		p.states[idx].pruned = true
+		p.mu.Unlock()
+		return p.finishNode(ctx, idx, StatusSkipped)
+	}
+	opCtx, cancel := ctx, context.CancelFunc(func() {})
+	if deadline := p.nodeDeadline(idx, startTime); !deadline.IsZero() {
+		opCtx, cancel = context.WithDeadline(ctx, deadline)
+	}
+	if p.tpl.racing[idx] {
+		var ok bool
+		deadlineCancel := cancel
+		if opCtx, cancel, ok = p.raceContext(opCtx, idx); !ok {
+			deadlineCancel()
+			return p.completeNode(ctx, idx, global, in, nil, ErrRaceLost)
+		}
+		raceCancel := cancel
+		cancel = func() {
+			raceCancel()
+			deadlineCancel()
 		}
-		close(doneChan) // close can make chan readable
+	}
+	if p.isInline(idx) { // done is called before runOp returns
+		var output interface{}
+		var err error
+		p.runOp(opCtx, idx, global, in, func(o interface{}, e error) {
+			output, err = o, e
+		})
+		cancel()
+		return p.completeNode(ctx, idx, global, in, output, err)
+	}
+	runner := p.runOp
+	if node.mapped {
+		runner = p.runMap
+	}
+	runner(opCtx, idx, global, in, func(output interface{}, err error) {
+		cancel()
+		p.dispatch(ctx, p.completeNode(ctx, idx, global, in, output, err))
 	})
-	if node.timeout > 0 {
-		select {
-		case <-ctx.Done():
-			// fmt.Println("timeout", node, ctx)
-			node.isCanceled = true
-		case <-doneChan:
-			node.isCanceled = false
+	return nil
+}
+
+// completeNode records the outcome of the op of node idx and finishes the
+// node once its fallback if any returned, it returns the children made ready
+func (p *DAG) completeNode(ctx context.Context, idx int, global interface{}, in Inputs, output interface{}, err error) []int {
+	node := p.tpl.nodes[idx]
+	p.mu.Lock()
+	startTime := p.states[idx].startTime
+	p.states[idx].returned = true // cancelSlower leaves the node alone from now on
+	lost := p.states[idx].lost
+	p.mu.Unlock()
+	if err == nil && node.op != nil {
+		err = node.checkOutputs(output)
+	}
+	var selected []int
+	if err == nil && node.switcher {
+		selected, err = p.selectChildren(idx, output)
+	}
+	status := StatusSuccess
+	switch {
+	case lost && err != nil: // canceled by cancelSlower
+		status, err = StatusCanceled, ErrRaceLost
+	case err == nil:
+		p.tpl.observe(idx, time.Since(startTime))
+	case ctx.Err() != nil:
+		status = StatusCanceled
+	case err == ErrTimeout: // if timeout, no need to save output
+		status = StatusTimeout
+	default:
+		status = StatusFailed
+		if _, ok := err.(*PanicError); ok {
+			status = StatusPanic
 		}
-	} else {
-		<- doneChan
-		node.isCanceled = false
-	}
-	
-	node.costTime = time.Now().Sub(startTime)
-	Go(func() {
-		for _, nextOne := range node.next {
-			p.mu.Lock()
-			nextOne.indegree--
-			indegree := nextOne.indegree
-			if indegree == 0 {
-				p.activeNum++ // should add before chan put
+	}
+	if node.fallback == nil || (status != StatusTimeout && !status.failed()) {
+		return p.recordNode(ctx, idx, status, err, output, selected, false, nil)
+	}
+	if node.fallback.op == nil {
+		return p.recordNode(ctx, idx, status, err, node.fallback.value, selected, true, nil)
+	}
+	p.runFallback(ctx, idx, global, in, func(fallbackOutput interface{}, fallbackErr error) {
+		p.dispatch(ctx, p.recordNode(ctx, idx, status, err, fallbackOutput, selected, fallbackErr == nil, fallbackErr))
+	})
+	return nil
+}
+
+// recordNode records the outcome of node idx and finishes it, output is the
+// one of the fallback if used. fallbackErr is the error of the fallback op.
+func (p *DAG) recordNode(ctx context.Context, idx int, status NodeStatus, err error, output interface{}, selected []int,
+	usedFallback bool, fallbackErr error) []int {
+	node := p.tpl.nodes[idx]
+	if usedFallback && node.switcher {
+		selected, _ = p.selectChildren(idx, output) // an invalid fallback selects none
+	}
+	endTime := time.Now()
+	p.releaseResources(idx)
+
+	if node.op != nil && (status == StatusSuccess || usedFallback) { // the start node has no output
+		p.saveOutput(node, output)
+	}
+	if status.failed() && !usedFallback {
+		p.mu.Lock()
+		p.errs = append(p.errs, NodeError{ID: node.id, Err: err})
+		if p.policy == FailFast && !p.aborted {
+			p.aborted = true
+			p.cancel()
+		}
+		p.mu.Unlock()
+	}
+	p.mu.Lock()
+	timedOut := status == StatusTimeout || fallbackErr == ErrTimeout
+	if timedOut && !usedFallback && !p.runDeadline.IsZero() && !endTime.Before(p.runDeadline) {
+		p.overrun = true
+	}
+	p.states[idx].err = err
+	p.states[idx].fallback = usedFallback
+	p.states[idx].switched = node.switcher && (status == StatusSuccess || usedFallback)
+	p.states[idx].selected = selected
+	p.states[idx].endTime = endTime
+	p.mu.Unlock()
+	return p.finishNode(ctx, idx, status)
+}
+
+// runFallback runs the fallback op of node idx as an attempt of the node
+// under its timeout and its deadline, so that it is abandoned with ErrTimeout
+// like the op, and calls done with its result
+func (p *DAG) runFallback(ctx context.Context, idx int, global interface{}, in Inputs, done func(output interface{}, err error)) {
+	cancel := context.CancelFunc(func() {})
+	if deadline := p.nodeDeadline(idx, time.Now()); !deadline.IsZero() {
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+	}
+	if ctx.Err() != nil { // e.g. the run deadline passed
+		cancel()
+		done(nil, ErrTimeout)
+		return
+	}
+	p.runAttempt(ctx, idx, 0, p.tpl.nodes[idx].fallback.op, global, in, func(output interface{}, err error) {
+		cancel()
+		done(output, err)
+	})
+}
+
+// runOp runs the op of node, retrying by node.retry, and calls done with the
+// result of the last attempt
+func (p *DAG) runOp(ctx context.Context, idx int, global interface{}, in Inputs, done func(output interface{}, err error)) {
+	p.retryOp(ctx, idx, global, in, func(attempt int) {
+		p.mu.Lock()
+		p.states[idx].attempts = attempt
+		p.mu.Unlock()
+	}, done)
+}
+
+// retryOp runs the op of node with the given input, retrying by node.retry,
+// onAttempt is called before each attempt
+func (p *DAG) retryOp(ctx context.Context, idx int, global interface{}, in Inputs, onAttempt func(attempt int), done func(output interface{}, err error)) {
+	node := p.tpl.nodes[idx]
+	if node.retry.TotalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, node.retry.TotalTimeout)
+		next := done
+		done = func(output interface{}, err error) {
+			cancel()
+			next(output, err)
+		}
+	}
+	var run func(attempt int)
+	run = func(attempt int) {
+		onAttempt(attempt)
+		p.runAttempt(ctx, idx, attempt, node.op, global, in, func(output interface{}, err error) {
+			if err == nil || !node.retry.shouldRetry(attempt, err) {
+				done(output, err)
+				return
 			}
-			p.mu.Unlock()
-			if indegree == 0 {
-				p.taskChan <- nextOne
+			timer := time.NewTimer(node.retry.backoff(attempt))
+			select {
+			case <-timer.C:
+				run(attempt + 1)
+			case <-ctx.Done():
+				timer.Stop()
+				done(output, err)
 			}
+		})
+	}
+	run(1)
+}
+
+// runAttempt submits op, the op of node idx or its fallback, to the executor
+// and calls done with its result, an attempt exceeding the timeout of the node
+// (or the total retry budget) or canceled with the run is abandoned and done
+// gets ErrTimeout
+func (p *DAG) runAttempt(ctx context.Context, idx int, attempt int, op Op, global interface{}, in Inputs, done func(output interface{}, err error)) {
+	node := p.tpl.nodes[idx]
+	cancel := context.CancelFunc(func() {})
+	if node.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, node.timeout)
+	}
+	ctx = &opContext{Context: ctx, id: node.id, attempt: attempt}
+	if p.isInline(idx) {
+		output, err := p.runInline(ctx, idx, op, global, in)
+		cancel()
+		done(output, err)
+		return
+	}
+
+	inv := &invocation{op: AbandonedOp{ID: node.id, Attempt: attempt, StartTime: time.Now()}, idx: idx}
+	p.mu.Lock()
+	inv.state = &p.states[idx]
+	inv.state.running++
+	p.mu.Unlock()
+	// whichever of the op and the context comes first continues the run
+	stop := context.AfterFunc(ctx, func() {
+		p.abandon(inv)
+		p.submit(func() {
+			done(nil, ErrTimeout)
+		})
+	})
+	p.submitNode(idx, func() {
+		if ctx.Err() != nil { // abandoned while queued, no need to run
+			p.opReturned(inv)
+			cancel()
+			return
 		}
 		p.mu.Lock()
-		p.activeNum--
-		activeNum := p.activeNum
+		if p.states[idx].startTime.IsZero() {
+			p.states[idx].startTime = time.Now() // the first attempt is running
+		}
 		p.mu.Unlock()
-		if activeNum == 0 {
-			close(p.doneChan)
+		output, err := callOp(ctx, op, global, in)
+		p.opReturned(inv)
+		if err != nil && ctx.Err() != nil { // op gave up because of the deadline
+			output, err = nil, ErrTimeout
+		}
+		continued := stop()
+		cancel()
+		if continued {
+			done(output, err)
 		}
 	})
 }
 
+// opContext is the context of an op attempt, it carries the node id and the
+// attempt number with one allocation rather than two context.WithValue
+type opContext struct {
+	context.Context
+	id      string
+	attempt int
+}
+
+func (c *opContext) Value(key interface{}) interface{} {
+	switch key {
+	case StateKey(NodeID):
+		return c.id
+	case StateKey(Attempt):
+		return c.attempt
+	}
+	return c.Context.Value(key)
+}
+
+// shouldSkip decides by the failure policy whether node should not run
+func (p *DAG) shouldSkip(idx int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.policy == FailFast && p.aborted {
+		return true
+	}
+	if p.tpl.nodes[idx].trigger != TriggerDefault {
+		return !p.triggerMet(idx)
+	}
+	switch p.policy {
+	case FailFast:
+		return p.aborted
+	case ContinueAll:
+		return false
+	default:
+		for _, parent := range p.tpl.prev[idx] {
+			status := p.states[parent].status
+			if (status.failed() && !p.states[parent].fallback) || (status == StatusSkipped && !p.states[parent].pruned) ||
+				(status == StatusCanceled && p.states[parent].lost) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// finishNode records the status of node and returns its children made ready,
+// the DAG is closed when the last active node is finished
+func (p *DAG) finishNode(ctx context.Context, idx int, status NodeStatus) []int {
+	p.mu.Lock()
+	p.states[idx].status = status
+	p.states[idx].finished = true
+	succeeded := status == StatusSuccess || p.states[idx].fallback
+	p.mu.Unlock()
+	var ready []int
+	for _, nextOne := range p.tpl.next[idx] {
+		if p.fire(nextOne, idx, succeeded) {
+			ready = append(ready, nextOne)
+		}
+	}
+	if len(ready) > 0 {
+		p.sortReady(ready)
+		now := time.Now()
+		p.mu.Lock()
+		for _, nextOne := range ready {
+			p.states[nextOne].readyTime = now
+		}
+		ready = p.markInline(ready)
+		p.mu.Unlock()
+	}
+	atomic.AddInt32(&p.activeNum, int32(len(ready))) // should add before the node is done
+	if atomic.AddInt32(&p.activeNum, -1) == 0 {
+		close(p.doneChan)
+	}
+	return ready
+}
+
 func (d *DAG) GetStateKeeper() StateKeeper {
 	return d.stateKeeper
 }
+
+// GetNodeStatus returns the status of node "id" in the run, a node which is
+// never scheduled is StatusNotStarted
+func (d *DAG) GetNodeStatus(id string) NodeStatus {
+	idx, ok := d.tpl.index[id]
+	if !ok {
+		return StatusNotStarted
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.states[idx].status
+}
+
+// GetAllNodeStatus returns the status of every node in the run
+func (d *DAG) GetAllNodeStatus() map[string]NodeStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	status := make(map[string]NodeStatus, len(d.states))
+	for i := range d.states {
+		status[d.tpl.nodes[i].id] = d.states[i].status
+	}
+	return status
+}
+
+// GetRunStatus groups the nodes of the run by how far they got
+func (d *DAG) GetRunStatus() RunStatus {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var status RunStatus
+	for i := range d.states {
+		id := d.tpl.nodes[i].id
+		switch d.states[i].status {
+		case StatusNotStarted:
+			status.NotStarted = append(status.NotStarted, id)
+		case StatusCanceled:
+			status.Canceled = append(status.Canceled, id)
+		case StatusSkipped:
+			status.Skipped = append(status.Skipped, id)
+		default:
+			status.Completed = append(status.Completed, id)
+		}
+	}
+	return status
+}
+
+// GetTemplate returns the compiled graph of the run
+func (d *DAG) GetTemplate() *Template {
+	return d.tpl
+}
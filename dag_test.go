@@ -3,8 +3,10 @@ package godag
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/stretchr/testify/assert"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -29,9 +31,11 @@ func (o *SimpleOp) Process(ctx context.Context, global interface{}, input ...int
 func TestSimple(t *testing.T) {
 	fmt.Println("TestSimple...")
 	nodeMap := make(map[string]*Node)
+	var dag DAG
 	checker := func (nodeID string) {
-		node := nodeMap[nodeID]
-		assert.Equal(t, 0, node.indegree)	// check the indegree of running node is 0
+		dag.mu.Lock()
+		defer dag.mu.Unlock()
+		assert.Equal(t, int32(0), atomic.LoadInt32(&dag.states[dag.tpl.index[nodeID]].indegree))	// check the indegree of running node is 0
 	}
 	/**
            |-> op1 -> op3 -> |
@@ -49,9 +53,8 @@ func TestSimple(t *testing.T) {
 	nodeMap["op3"] = op3
 	nodeMap["op4"] = op4
 
-	var dag DAG
-	r := dag.Init(start, nil)
-	assert.True(t, r)
+	err := dag.Init(start, nil)
+	assert.NoError(t, err)
 	startTime := time.Now()
 	fmt.Println(startTime, "start")
 	dag.Execute(context.TODO())
@@ -76,8 +79,8 @@ func TestTimeout(t *testing.T) {
 	op2.AddNextNode(op4)
 
 	var dag DAG
-	r := dag.Init(start, nil)
-	assert.True(t, r)
+	err := dag.Init(start, nil)
+	assert.NoError(t, err)
 	startTime := time.Now()
 	fmt.Println(startTime, "start")
 	dag.Execute(context.TODO())
@@ -140,8 +143,8 @@ func TestComplex(t *testing.T) {
 
 	fmt.Println("output 3 op=", ds_uniq_exp, fe_pvreal, fe_pvreal2)
 	var dag DAG
-	r := dag.Init(start, nil)
-	assert.True(t, r)
+	err := dag.Init(start, nil)
+	assert.NoError(t, err)
 	startTime := time.Now()
 	fmt.Println(startTime, "start")
 	dag.Execute(context.TODO())
@@ -176,4 +179,144 @@ func TestLeftJoin(t *testing.T) {
 	assert.Equal(t, ds_left_join.prev[0], ds4)
 	assert.Equal(t, ds_left_join.prev[1], ds_all_play)
 	assert.Equal(t, ds_left_join.prev[2], ds3)
-}
\ No newline at end of file
+}
+type ErrOp struct {
+	data string
+	err  error
+}
+
+func (o *ErrOp) ProcessE(ctx context.Context, global interface{}, input ...interface{}) (interface{}, error) {
+	if o.err != nil {
+		return nil, o.err
+	}
+	return o.data, nil
+}
+
+func TestOpError(t *testing.T) {
+	fmt.Println("TestOpError...")
+	/**
+           |-> op1(fail) -> op3 -> |
+    start->|                       |-> op4
+           |-> op2 --------------->|
+	**/
+	start := NewStartNode("start")
+	op1 := start.AddNextE("op1", &ErrOp{err: errors.New("boom")})
+	op2 := start.AddNextE("op2", &ErrOp{data: "op2_data"})
+	op3 := op1.AddNext("op3", &SimpleOp{data: "op3_data"})
+	op4 := op3.AddNext("op4", &SimpleOp{data: "op4_data"})
+	op2.AddNextNode(op4)
+
+	var dag DAG
+	dag.Init(start, nil)
+	err := dag.Execute(context.TODO())
+	assert.Error(t, err)
+	runErr, ok := err.(*RunError)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"op1"}, runErr.FailedIDs())
+	assert.Contains(t, err.Error(), "op1: boom")
+
+	outputs := dag.GetStateKeeper().GetAllOutput()
+	assert.Equal(t, map[string]interface{}{"op2": "op2_data"}, outputs)
+	assert.Equal(t, StatusFailed, dag.GetNodeStatus("op1"))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("op2"))
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("op3"))
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("op4"))
+}
+
+func TestFailurePolicy(t *testing.T) {
+	fmt.Println("TestFailurePolicy...")
+	/**
+           |-> op1(fail) -> op3
+    start->|
+           |-> op2 -> op4
+	**/
+	build := func() *Node {
+		start := NewStartNode("start")
+		start.AddNextE("op1", &ErrOp{err: errors.New("boom")}).AddNext("op3", &SimpleOp{data: "op3_data"})
+		start.AddNext("op2", &SimpleOp{data: "op2_data", processTime: 100 * time.Millisecond}).
+			AddNext("op4", &SimpleOp{data: "op4_data"})
+		return start
+	}
+
+	var dag DAG
+	dag.Init(build(), nil)
+	dag.WithFailurePolicy(ContinueAll)
+	assert.Error(t, dag.Execute(context.TODO()))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("op3"))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("op4"))
+
+	dag.Init(build(), nil)
+	dag.WithFailurePolicy(SkipDescendants)
+	assert.Error(t, dag.Execute(context.TODO()))
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("op3"))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("op4"))
+
+	dag.Init(build(), nil)
+	dag.WithFailurePolicy(FailFast)
+	assert.Error(t, dag.Execute(context.TODO()))
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("op3"))
+	assert.Equal(t, StatusCanceled, dag.GetNodeStatus("op2")) // running when op1 failed
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("op4"))
+	_, computed := dag.GetStateKeeper().GetAllOutput()["op4"]
+	assert.False(t, computed)
+}
+
+func TestCancel(t *testing.T) {
+	fmt.Println("TestCancel...")
+	/**
+           |-> op1 -> op2 -> op3
+    start->|
+           |-> op4
+	**/
+	start := NewStartNode("start")
+	start.AddNext("op1", &SimpleOp{data: "op1_data", processTime: 10 * time.Millisecond}).
+		AddNext("op2", &SimpleOp{data: "op2_data", processTime: time.Second}). // ignores ctx
+		AddNext("op3", &SimpleOp{data: "op3_data"})
+	start.AddNext("op4", &SimpleOp{data: "op4_data", processTime: time.Second})
+
+	var dag DAG
+	dag.Init(start, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	startTime := time.Now()
+	err := dag.Execute(ctx)
+	assert.True(t, time.Since(startTime) < 500*time.Millisecond)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	status := dag.GetRunStatus()
+	assert.ElementsMatch(t, []string{"start", "op1"}, status.Completed)
+	assert.ElementsMatch(t, []string{"op2", "op4"}, status.Canceled)
+	assert.ElementsMatch(t, []string{"op3"}, status.NotStarted)
+	assert.Equal(t, &status, err.(*RunError).Status)
+	assert.Equal(t, map[string]interface{}{"op1": "op1_data"}, dag.GetStateKeeper().GetAllOutput())
+}
+
+type PanicOp struct{}
+
+func (o *PanicOp) Process(ctx context.Context, global interface{}, input ...interface{}) interface{} {
+	var m map[string]int
+	m["crash"] = 1
+	return nil
+}
+
+func TestPanic(t *testing.T) {
+	fmt.Println("TestPanic...")
+	start := NewStartNode("start")
+	start.AddNext("op1", &PanicOp{}).AddNext("op2", &SimpleOp{data: "op2_data"})
+	start.AddNext("op3", &SimpleOp{data: "op3_data"})
+
+	var dag DAG
+	dag.Init(start, nil)
+	err := dag.Execute(context.TODO())
+	assert.Error(t, err)
+	runErr := err.(*RunError)
+	assert.Equal(t, []string{"op1"}, runErr.FailedIDs())
+	panicErr, ok := runErr.Nodes[0].Err.(*PanicError)
+	assert.True(t, ok)
+	assert.Contains(t, panicErr.Error(), "assignment to entry in nil map")
+	assert.Contains(t, string(panicErr.Stack), "PanicOp")
+
+	assert.Equal(t, StatusPanic, dag.GetNodeStatus("op1"))
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("op2"))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("op3"))
+}
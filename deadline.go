@@ -0,0 +1,70 @@
+package godag
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRunDeadline is the error of the nodes skipped because the run deadline
+// passed, and the Cause of the RunError of such a run
+var ErrRunDeadline = errors.New("godag: run deadline exceeded")
+
+// WithDeadline sets the deadline of the run. No op runs past the deadline (an
+// op still running is abandoned as timed out), and the nodes which become
+// ready after the deadline are skipped rather than started. If a node was
+// skipped or timed out so, Execute returns a *RunError of ErrRunDeadline.
+func (p *DAG) WithDeadline(deadline time.Time) *DAG {
+	p.deadline = deadline
+	return p
+}
+
+// WithTimeout sets the deadline of the run relative to the start of Execute
+func (p *DAG) WithTimeout(timeout time.Duration) *DAG {
+	p.timeout = timeout
+	return p
+}
+
+// WithDeadlineBudget makes each node share the time left before the run
+// deadline with the longest path after it: a node starting at t gets
+// (deadline - t) * estimate(node) / estimate(longest path from node), where
+// the estimate is declared by Node.WithExpectedDuration or observed by the
+// previous runs of the same Template. A node without estimate gets all the
+// time left.
+func (p *DAG) WithDeadlineBudget(enable bool) *DAG {
+	p.budget = enable
+	return p
+}
+
+// initDeadline computes the deadline of the run started at startTime
+func (p *DAG) initDeadline(startTime time.Time) {
+	p.runDeadline = p.deadline
+	p.estimates, p.tails = nil, nil
+	if p.timeout > 0 {
+		if d := startTime.Add(p.timeout); p.runDeadline.IsZero() || d.Before(p.runDeadline) {
+			p.runDeadline = d
+		}
+	}
+	if p.runDeadline.IsZero() || !p.budget {
+		return
+	}
+	p.estimates = make([]time.Duration, len(p.tpl.nodes))
+	for i := range p.estimates {
+		p.estimates[i] = p.tpl.estimate(i)
+	}
+	p.tails = p.tpl.longestPaths(func(idx int) time.Duration {
+		return p.estimates[idx]
+	})
+}
+
+// nodeDeadline returns the deadline of node idx started at now, zero if none
+func (p *DAG) nodeDeadline(idx int, now time.Time) time.Time {
+	if p.runDeadline.IsZero() || p.tails == nil {
+		return p.runDeadline
+	}
+	estimate := p.estimates[idx]
+	if estimate <= 0 {
+		return p.runDeadline
+	}
+	remaining := p.runDeadline.Sub(now)
+	return now.Add(time.Duration(float64(remaining) * float64(estimate) / float64(p.tails[idx])))
+}
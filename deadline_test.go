@@ -0,0 +1,90 @@
+package godag
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunDeadline(t *testing.T) {
+	start := NewStartNode("start")
+	start.AddNext("op1", &SimpleOp{data: "op1_data", processTime: 20 * time.Millisecond}).
+		AddNext("op2", &SimpleOp{data: "op2_data", processTime: 200 * time.Millisecond}).WithTimeout(time.Second).
+		AddNext("op3", &SimpleOp{data: "op3_data"})
+
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	dag.WithTimeout(60 * time.Millisecond)
+	startTime := time.Now()
+	err := dag.Execute(context.Background())
+	assert.True(t, time.Since(startTime) < 150*time.Millisecond)
+	assert.True(t, errors.Is(err, ErrRunDeadline))
+	assert.Equal(t, "godag: run deadline exceeded (3 completed, 0 canceled, 0 not started)", err.Error())
+
+	report := dag.GetReport()
+	assert.Equal(t, StatusSuccess, report.Node("op1").Status)
+	assert.Equal(t, StatusTimeout, report.Node("op2").Status) // capped by the run deadline
+	assert.Equal(t, StatusSkipped, report.Node("op3").Status)
+	assert.Equal(t, ErrRunDeadline.Error(), report.Node("op3").Error)
+}
+
+func TestDeadlineBudget(t *testing.T) {
+	start := NewStartNode("start")
+	op1 := start.AddNext("op1", &SimpleOp{data: "op1_data", processTime: 100 * time.Millisecond}).
+		WithExpectedDuration(20 * time.Millisecond)
+	op1.AddNext("op2", &SimpleOp{data: "op2_data", processTime: 10 * time.Millisecond}).
+		WithExpectedDuration(60 * time.Millisecond)
+	tpl, err := Compile(start)
+	assert.NoError(t, err)
+
+	// op1 gets 1/4 of the budget, so op2 still has time to run
+	dag := tpl.NewDAG(nil).WithTimeout(200 * time.Millisecond).WithDeadlineBudget(true)
+	assert.NoError(t, dag.Execute(context.Background()))
+	report := dag.GetReport()
+	assert.Equal(t, StatusTimeout, report.Node("op1").Status)
+	assert.True(t, report.Node("op1").Duration < 90*time.Millisecond)
+	assert.Equal(t, StatusSuccess, report.Node("op2").Status)
+
+	// without budget op1 takes all the time it needs
+	dag = tpl.NewDAG(nil).WithTimeout(200 * time.Millisecond)
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("op1"))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("op2"))
+}
+
+func TestObservedDuration(t *testing.T) {
+	start := NewStartNode("start")
+	start.AddNext("op1", &SimpleOp{data: "op1_data", processTime: 10 * time.Millisecond})
+	tpl, err := Compile(start)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), tpl.estimate(1))
+
+	assert.NoError(t, tpl.NewDAG(nil).Execute(context.Background()))
+	assert.True(t, tpl.estimate(1) >= 10*time.Millisecond)
+
+	tpl.observe(1, 0)
+	tpl.observe(1, 0)
+	assert.True(t, tpl.estimate(1) < 10*time.Millisecond)
+}
+
+func TestDeadlineReInit(t *testing.T) {
+	start := NewStartNode("start")
+	start.AddNext("op1", &SimpleOp{data: "op1_data", processTime: 50 * time.Millisecond})
+
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	dag.WithTimeout(20 * time.Millisecond).WithDeadlineBudget(true)
+	assert.True(t, errors.Is(dag.Execute(context.Background()), ErrRunDeadline))
+
+	// a larger graph without budget does not inherit the state of the last run
+	start = NewStartNode("start")
+	start.AddNext("op1", &SimpleOp{data: "op1_data"}).
+		AddNext("op2", &SimpleOp{data: "op2_data"})
+	assert.NoError(t, dag.Init(start, nil))
+	dag.WithTimeout(time.Second).WithDeadlineBudget(false)
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("op2"))
+}
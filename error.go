@@ -0,0 +1,70 @@
+package godag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// NodeError is the error returned by the op of a node
+type NodeError struct {
+	ID  string
+	Err error
+}
+
+func (e NodeError) Error() string {
+	return e.ID + ": " + e.Err.Error()
+}
+
+// PanicError is the error of an op which panicked
+type PanicError struct {
+	Value interface{} // the value passed to panic
+	Stack []byte      // stack trace of the goroutine running the op
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// RunError is returned by DAG.Execute when some nodes failed or the run was canceled
+type RunError struct {
+	Nodes  []NodeError
+	Cause  error      // error of the context if the run was canceled, or ErrRunDeadline
+	Status *RunStatus // status of the nodes if the run was canceled or missed its deadline
+}
+
+func (e *RunError) Error() string {
+	var msgs []string
+	if e.Cause != nil {
+		msg := "run canceled: " + e.Cause.Error()
+		if e.Cause == ErrRunDeadline {
+			msg = "run deadline exceeded"
+		}
+		if e.Status != nil {
+			msg += fmt.Sprintf(" (%d completed, %d canceled, %d not started)",
+				len(e.Status.Completed), len(e.Status.Canceled), len(e.Status.NotStarted))
+		}
+		msgs = append(msgs, msg)
+	}
+	if len(e.Nodes) > 0 {
+		failed := make([]string, len(e.Nodes))
+		for i := range e.Nodes {
+			failed[i] = e.Nodes[i].Error()
+		}
+		msgs = append(msgs, fmt.Sprintf("%d node(s) failed: %s", len(e.Nodes), strings.Join(failed, "; ")))
+	}
+	return "godag: " + strings.Join(msgs, ", ")
+}
+
+// Unwrap returns the error of the context if the run was canceled
+func (e *RunError) Unwrap() error {
+	return e.Cause
+}
+
+// FailedIDs returns the id of failed nodes
+func (e *RunError) FailedIDs() []string {
+	ids := make([]string, len(e.Nodes))
+	for i := range e.Nodes {
+		ids[i] = e.Nodes[i].ID
+	}
+	return ids
+}
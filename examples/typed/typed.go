@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yanjunz/godag"
+)
+
+func main() {
+	start := godag.NewStartNode("start")
+	words := godag.AddTyped0(start, "words", func(ctx context.Context) ([]string, error) {
+		return strings.Fields("the quick brown fox"), nil
+	})
+	count := godag.AddTyped1(words, "count", func(ctx context.Context, words []string) (int, error) {
+		return len(words), nil
+	})
+	longest := godag.AddTyped1(words, "longest", func(ctx context.Context, words []string) (string, error) {
+		longest := ""
+		for _, w := range words {
+			if len(w) > len(longest) {
+				longest = w
+			}
+		}
+		return longest, nil
+	})
+	summary := godag.AddTyped2(count, longest, "summary", func(ctx context.Context, count int, longest string) (string, error) {
+		return fmt.Sprintf("%d words, the longest is %q", count, longest), nil
+	})
+
+	var dag godag.DAG
+	if err := dag.Init(start, nil); err != nil {
+		fmt.Println(err)
+		return
+	}
+	dag.Execute(context.TODO())
+	output, _ := summary.Output(dag.GetStateKeeper())
+	fmt.Println(output)
+}
@@ -0,0 +1,203 @@
+package godag
+
+import (
+	"container/heap"
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Executor runs the tasks of a run. A task runs the op of a node, then on the
+// same goroutine releases its children and submits their ops, so a node costs
+// one task. The continuation of a node which timed out or waited for its
+// resources is a task as well. Submit should not block for long, see Pool.
+type Executor interface {
+	Submit(task func())
+}
+
+// GoExecutor runs each task on a new goroutine by the package-level Go, it is
+// the executor of a run without WithExecutor
+type GoExecutor struct{}
+
+func (GoExecutor) Submit(task func()) {
+	Go(task)
+}
+
+// WithExecutor sets the executor running the tasks of the run, an executor
+// can be shared by many runs
+func (p *DAG) WithExecutor(executor Executor) *DAG {
+	p.executor = executor
+	return p
+}
+
+// submit runs task by the executor of the run
+func (p *DAG) submit(task func()) {
+	if p.executor == nil {
+		Go(task)
+		return
+	}
+	p.executor.Submit(task)
+}
+
+// PriorityExecutor is an Executor which runs the queued tasks with higher
+// priority first, the tasks submitted by Submit have the highest priority
+type PriorityExecutor interface {
+	Executor
+	SubmitPriority(task func(), priority int64)
+}
+
+// Pool is a PriorityExecutor with a fixed number of workers and a bounded
+// queue. When the queue is full the task runs on the goroutine submitting it,
+// which slows down the submitter rather than blocking it: a worker submitting
+// the children of its node can never deadlock the pool.
+//
+// So a Pool is not a bound of the ops running at the same time: once the
+// queue is full an op runs on whichever goroutine submits it, a worker, the
+// caller of Execute, the timer of a timeout or the node releasing resources.
+// Size the queue to the fan-out of the runs to keep the ops on the workers,
+// Stats().Saturated counts the others. Use Node.WithResource to bound the
+// concurrency of ops.
+type Pool struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	queue     taskQueue
+	queueSize int
+	seq       int64 // order of submission, guarded by mu
+	idle      int   // workers waiting for a task, guarded by mu
+	closed    bool
+	workers   int
+	wg        sync.WaitGroup
+	busy      int64 // workers running a task, accessed atomically
+	submitted int64 // accessed atomically
+	saturated int64 // tasks run by the submitter as the queue was full, accessed atomically
+}
+
+// PoolStats is a snapshot of the metrics of a Pool
+type PoolStats struct {
+	Workers    int   // number of workers
+	Busy       int   // workers running a task
+	QueueDepth int   // tasks waiting for a worker
+	QueueSize  int   // capacity of the queue
+	Submitted  int64 // tasks submitted since the pool was created
+	Saturated  int64 // tasks run by the submitter because the queue was full
+}
+
+// NewPool starts a pool of workers goroutines with a queue of queueSize tasks,
+// workers should be > 0. Close the pool once no run uses it.
+func NewPool(workers, queueSize int) *Pool {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+	p := &Pool{
+		queueSize: queueSize,
+		workers:   workers,
+	}
+	p.cond = sync.NewCond(&p.mu)
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	for {
+		p.mu.Lock()
+		for len(p.queue) == 0 && !p.closed {
+			p.idle++
+			p.cond.Wait()
+			p.idle--
+		}
+		if len(p.queue) == 0 { // closed
+			p.mu.Unlock()
+			return
+		}
+		t := heap.Pop(&p.queue).(queuedTask)
+		atomic.AddInt64(&p.busy, 1)
+		p.mu.Unlock()
+		t.task()
+		atomic.AddInt64(&p.busy, -1)
+	}
+}
+
+// Submit queues task ahead of the prioritized ones, or runs it on the calling
+// goroutine if the queue is full
+func (p *Pool) Submit(task func()) {
+	p.SubmitPriority(task, math.MaxInt64)
+}
+
+// SubmitPriority queues task, the tasks of the same priority run in the order
+// of submission. If the queue is full the task runs on the calling goroutine.
+func (p *Pool) SubmitPriority(task func(), priority int64) {
+	atomic.AddInt64(&p.submitted, 1)
+	p.mu.Lock()
+	if len(p.queue) >= p.queueSize+p.idle { // the idle workers take the task at once
+		p.mu.Unlock()
+		atomic.AddInt64(&p.saturated, 1)
+		task()
+		return
+	}
+	p.seq++
+	heap.Push(&p.queue, queuedTask{task: task, priority: priority, seq: p.seq})
+	p.mu.Unlock()
+	p.cond.Signal()
+}
+
+// Stats returns the current metrics of the pool
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	depth := len(p.queue)
+	p.mu.Unlock()
+	return PoolStats{
+		Workers:    p.workers,
+		Busy:       int(atomic.LoadInt64(&p.busy)),
+		QueueDepth: depth,
+		QueueSize:  p.queueSize,
+		Submitted:  atomic.LoadInt64(&p.submitted),
+		Saturated:  atomic.LoadInt64(&p.saturated),
+	}
+}
+
+// Close stops the workers after the queued tasks are done, no task should be submitted after Close
+func (p *Pool) Close() {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+	p.cond.Broadcast()
+	p.wg.Wait()
+}
+
+// queuedTask is a task waiting in the queue of a Pool
+type queuedTask struct {
+	task     func()
+	priority int64
+	seq      int64
+}
+
+// taskQueue is a heap of tasks by priority then by order of submission
+type taskQueue []queuedTask
+
+func (q taskQueue) Len() int { return len(q) }
+
+func (q taskQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q taskQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *taskQueue) Push(x interface{}) { *q = append(*q, x.(queuedTask)) }
+
+func (q *taskQueue) Pop() interface{} {
+	old := *q
+	t := old[len(old)-1]
+	old[len(old)-1] = queuedTask{}
+	*q = old[:len(old)-1]
+	return t
+}
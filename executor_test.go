@@ -0,0 +1,122 @@
+package godag
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// CountOp records how many ops run at the same time
+type CountOp struct {
+	running *int32
+	max     *int32
+	sleep   time.Duration
+}
+
+func (o *CountOp) Process(ctx context.Context, global interface{}, input ...interface{}) interface{} {
+	n := atomic.AddInt32(o.running, 1)
+	for {
+		max := atomic.LoadInt32(o.max)
+		if n <= max || atomic.CompareAndSwapInt32(o.max, max, n) {
+			break
+		}
+	}
+	time.Sleep(o.sleep)
+	atomic.AddInt32(o.running, -1)
+	return ctx.Value(StateKey(NodeID))
+}
+
+func TestPoolExecutor(t *testing.T) {
+	var running, max int32
+	start := NewStartNode("start")
+	for i := 0; i < 8; i++ {
+		id := fmt.Sprintf("op%d", i)
+		start.AddNext(id, &CountOp{running: &running, max: &max, sleep: 20 * time.Millisecond}).
+			AddNext(id+"_next", &CountOp{running: &running, max: &max, sleep: 20 * time.Millisecond})
+	}
+	tpl, err := Compile(start)
+	assert.NoError(t, err)
+
+	pool := NewPool(3, 16)
+	defer pool.Close()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ { // the runs share the pool
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			dag := tpl.NewDAG(nil).WithExecutor(pool)
+			assert.NoError(t, dag.Execute(context.Background()))
+			assert.Equal(t, 16, len(dag.GetStateKeeper().GetAllOutput()))
+		}()
+	}
+	wg.Wait()
+	assert.True(t, atomic.LoadInt32(&max) <= 3, "max concurrent ops %d", max)
+
+	stats := pool.Stats()
+	assert.Equal(t, 3, stats.Workers)
+	assert.Equal(t, 16, stats.QueueSize)
+	assert.True(t, stats.Submitted > 0)
+}
+
+func TestPoolSaturated(t *testing.T) {
+	pool := NewPool(1, 1)
+	block := make(chan struct{})
+	pool.Submit(func() { <-block }) // occupies the worker
+	for pool.Stats().Busy == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	pool.Submit(func() {}) // queued
+	assert.Equal(t, 1, pool.Stats().QueueDepth)
+
+	ran := false
+	pool.Submit(func() { ran = true }) // queue is full, run by the caller
+	assert.True(t, ran)
+	assert.Equal(t, int64(1), pool.Stats().Saturated)
+	assert.Equal(t, int64(3), pool.Stats().Submitted)
+
+	close(block)
+	pool.Close()
+	assert.Equal(t, 0, pool.Stats().Busy)
+	assert.Equal(t, 0, pool.Stats().QueueDepth)
+}
+
+func TestPoolSaturatedRuns(t *testing.T) {
+	var running, max int32
+	start := NewStartNode("start")
+	for i := 0; i < 8; i++ { // fan-out larger than the queue
+		start.AddNext(fmt.Sprintf("op%d", i), &CountOp{running: &running, max: &max, sleep: 20 * time.Millisecond}).
+			WithResource("cpu", 1)
+	}
+	tpl, err := Compile(start)
+	assert.NoError(t, err)
+
+	pool := NewPool(2, 2)
+	defer pool.Close()
+	runAll := func(resources *Resources) {
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				dag := tpl.NewDAG(nil).WithExecutor(pool).WithResources(resources)
+				assert.NoError(t, dag.Execute(context.Background()))
+				assert.Equal(t, 8, len(dag.GetStateKeeper().GetAllOutput()))
+			}()
+		}
+		wg.Wait()
+	}
+
+	// the ops not queued run on the goroutines submitting them
+	runAll(nil)
+	assert.True(t, pool.Stats().Saturated > 0)
+
+	// the resource rather than the pool bounds the ops running at the same time
+	atomic.StoreInt32(&max, 0)
+	runAll(NewResources(map[string]int{"cpu": 2}))
+	assert.True(t, atomic.LoadInt32(&max) <= 2, "max concurrent ops %d", max)
+}
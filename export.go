@@ -0,0 +1,175 @@
+package godag
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// colors of the node status in the annotated export
+var statusColors = map[NodeStatus]string{
+	StatusNotStarted: "#ffffff",
+	StatusSuccess:    "#b7e1a1",
+	StatusTimeout:    "#f9c74f",
+	StatusFailed:     "#f28482",
+	StatusSkipped:    "#d3d3d3",
+	StatusCanceled:   "#cdb4db",
+	StatusPanic:      "#d62828",
+}
+
+// nodeAnnotation is the outcome of a node shown in the annotated export
+type nodeAnnotation struct {
+	status   NodeStatus
+	fallback bool
+	costTime time.Duration
+}
+
+// ExportDOT compiles the graph from startNode and exports it in Graphviz DOT
+func ExportDOT(startNode *Node) (string, error) {
+	tpl, err := Compile(startNode)
+	if err != nil {
+		return "", err
+	}
+	return tpl.ExportDOT(), nil
+}
+
+// ExportMermaid compiles the graph from startNode and exports it as a Mermaid flowchart
+func ExportMermaid(startNode *Node) (string, error) {
+	tpl, err := Compile(startNode)
+	if err != nil {
+		return "", err
+	}
+	return tpl.ExportMermaid(), nil
+}
+
+// ExportDOT exports the template in Graphviz DOT. Each node is labeled with
+// its id, op type and timeout, each edge with the index of the parent in the
+// args of the child.
+func (t *Template) ExportDOT() string {
+	return t.exportDOT(nil)
+}
+
+// ExportMermaid exports the template as a Mermaid flowchart, labeled the same as ExportDOT
+func (t *Template) ExportMermaid() string {
+	return t.exportMermaid(nil)
+}
+
+// ExportDOT exports the graph of the run in Graphviz DOT, nodes are colored
+// by status and labeled with the cost time
+func (d *DAG) ExportDOT() string {
+	return d.tpl.exportDOT(d.annotations())
+}
+
+// ExportMermaid exports the graph of the run as a Mermaid flowchart, annotated the same as ExportDOT
+func (d *DAG) ExportMermaid() string {
+	return d.tpl.exportMermaid(d.annotations())
+}
+
+func (d *DAG) annotations() []nodeAnnotation {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	annotations := make([]nodeAnnotation, len(d.states))
+	for i := range d.states {
+		annotations[i] = nodeAnnotation{
+			status:   d.states[i].status,
+			fallback: d.states[i].fallback,
+			costTime: d.states[i].costTime(),
+		}
+	}
+	return annotations
+}
+
+// nodeLabel returns the lines of the label of node idx
+func (t *Template) nodeLabel(idx int, annotations []nodeAnnotation) []string {
+	node := t.nodes[idx]
+	lines := []string{node.id}
+	if node.op != nil {
+		lines = append(lines, opTypeName(node.op))
+	}
+	if node.timeout > 0 {
+		lines = append(lines, "timeout="+node.timeout.String())
+	}
+	if annotations != nil {
+		a := annotations[idx]
+		line := a.status.String()
+		if a.fallback {
+			line += "+fallback"
+		}
+		if a.status != StatusNotStarted && a.status != StatusSkipped {
+			line += " " + a.costTime.String()
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func (t *Template) exportDOT(annotations []nodeAnnotation) string {
+	var b strings.Builder
+	b.WriteString("digraph godag {\n")
+	b.WriteString("\trankdir=LR;\n")
+	b.WriteString("\tnode [shape=box];\n")
+	for i := range t.nodes {
+		label := strings.Join(t.nodeLabel(i, annotations), "\n")
+		fmt.Fprintf(&b, "\t%s [label=%s", dotQuote(t.nodes[i].id), dotQuote(label))
+		if annotations != nil {
+			fmt.Fprintf(&b, ", style=filled, fillcolor=%s", dotQuote(statusColors[annotations[i].status]))
+		}
+		b.WriteString("];\n")
+	}
+	for i := range t.nodes {
+		for argIdx, parent := range t.prev[i] {
+			fmt.Fprintf(&b, "\t%s -> %s [label=\"%d\"];\n", dotQuote(t.nodes[parent].id), dotQuote(t.nodes[i].id), argIdx)
+		}
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+func (t *Template) exportMermaid(annotations []nodeAnnotation) string {
+	var b strings.Builder
+	b.WriteString("graph LR\n")
+	for i := range t.nodes {
+		lines := t.nodeLabel(i, annotations)
+		for j := range lines {
+			lines[j] = mermaidEscape(lines[j])
+		}
+		fmt.Fprintf(&b, "\tn%d[\"%s\"]\n", i, strings.Join(lines, "<br/>"))
+	}
+	for i := range t.nodes {
+		for argIdx, parent := range t.prev[i] {
+			fmt.Fprintf(&b, "\tn%d -->|%d| n%d\n", parent, argIdx, i)
+		}
+	}
+	if annotations != nil {
+		for status := StatusNotStarted; int(status) < len(statusNames); status++ {
+			fmt.Fprintf(&b, "\tclassDef %s fill:%s\n", status, statusColors[status])
+		}
+		for i := range t.nodes {
+			fmt.Fprintf(&b, "\tclass n%d %s\n", i, annotations[i].status)
+		}
+	}
+	return b.String()
+}
+
+// opTypeName returns the type name of op, the OpE wrapped by NewNodeE/AddNextE is unwrapped
+// and a typed op is shown by its signature
+func opTypeName(op Op) string {
+	if a, ok := op.(opEAdapter); ok {
+		if o, ok := a.OpE.(*typedOp); ok {
+			return o.signature()
+		}
+		return fmt.Sprintf("%T", a.OpE)
+	}
+	if a, ok := op.(inputsAdapter); ok {
+		return fmt.Sprintf("%T", a.InputsOp)
+	}
+	return fmt.Sprintf("%T", op)
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;").Replace(s)
+}
@@ -0,0 +1,68 @@
+package godag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport(t *testing.T) {
+	start := NewStartNode("start")
+	op1 := start.AddNext("op1", &ConcatOp{}).WithTimeout(time.Second)
+	op2 := start.AddNextE("op2", &ErrOp{})
+	op3 := op1.AddNext("op3", &ConcatOp{})
+	op2.AddNextNode(op3)
+
+	dot, err := ExportDOT(start)
+	assert.NoError(t, err)
+	assert.Equal(t, `digraph godag {
+	rankdir=LR;
+	node [shape=box];
+	"start" [label="start"];
+	"op1" [label="op1\n*godag.ConcatOp\ntimeout=1s"];
+	"op2" [label="op2\n*godag.ErrOp"];
+	"op3" [label="op3\n*godag.ConcatOp"];
+	"start" -> "op1" [label="0"];
+	"start" -> "op2" [label="0"];
+	"op1" -> "op3" [label="0"];
+	"op2" -> "op3" [label="1"];
+}
+`, dot)
+
+	mermaid, err := ExportMermaid(start)
+	assert.NoError(t, err)
+	assert.Equal(t, `graph LR
+	n0["start"]
+	n1["op1<br/>*godag.ConcatOp<br/>timeout=1s"]
+	n2["op2<br/>*godag.ErrOp"]
+	n3["op3<br/>*godag.ConcatOp"]
+	n0 -->|0| n1
+	n0 -->|0| n2
+	n1 -->|0| n3
+	n2 -->|1| n3
+`, mermaid)
+}
+
+func TestExportAnnotated(t *testing.T) {
+	start := NewStartNode("start")
+	start.AddNext("op1", &ConcatOp{})
+	start.AddNextE("op2", &ErrOp{err: errors.New("boom")}).AddNext("op3", &ConcatOp{})
+
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	assert.Error(t, dag.Execute(context.Background()))
+
+	dot := dag.ExportDOT()
+	assert.Contains(t, dot, fmt.Sprintf(`style=filled, fillcolor="%s"`, statusColors[StatusSuccess]))
+	assert.Contains(t, dot, `"op2" [label="op2\n*godag.ErrOp\nfailed `)
+	assert.Contains(t, dot, `"op3" [label="op3\n*godag.ConcatOp\nskipped", style=filled, fillcolor="#d3d3d3"];`)
+
+	mermaid := dag.ExportMermaid()
+	assert.Contains(t, mermaid, "\tclassDef failed fill:#f28482\n")
+	assert.Contains(t, mermaid, "\tclass n2 failed\n")
+	assert.Contains(t, mermaid, "\tclass n3 skipped\n")
+}
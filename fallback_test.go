@@ -0,0 +1,88 @@
+package godag
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFallback(t *testing.T) {
+	/**
+	           |-> op1(timeout, fallback value) -> |
+	    start->|-> op2(error, fallback op) ------> |-> op4
+	           |-> op3(panic, fallback fails) -> op5
+	**/
+	start := NewStartNode("start")
+	op1 := start.AddNext("op1", &SimpleOp{data: "op1_data", processTime: time.Second}).
+		WithTimeout(10 * time.Millisecond).WithFallback("op1_default")
+	op2 := start.AddNextE("op2", &ErrOp{err: errors.New("boom")}).WithFallback(&ConcatOp{name: "op2_fallback"})
+	start.AddNext("op3", &PanicOp{}).WithFallback(&ErrOp{err: errors.New("no fallback")}).
+		AddNext("op5", &SimpleOp{data: "op5_data"})
+	op4 := op1.AddNext("op4", &ConcatOp{name: "op4"})
+	op2.AddNextNode(op4)
+
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	err := dag.Execute(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, []string{"op3"}, err.(*RunError).FailedIDs())
+
+	sk := dag.GetStateKeeper()
+	assert.Equal(t, "op1_default", sk.GetOutput("op1"))
+	assert.Equal(t, "<nil>op2_fallback[<nil>]", sk.GetOutput("op2"))
+	assert.Equal(t, "<nil>op4[op1_default <nil>op2_fallback[<nil>]]", sk.GetOutput("op4"))
+
+	report := dag.GetReport()
+	assert.Equal(t, StatusTimeout, report.Node("op1").Status)
+	assert.True(t, report.Node("op1").Fallback)
+	assert.Equal(t, StatusFailed, report.Node("op2").Status)
+	assert.True(t, report.Node("op2").Fallback)
+	assert.Equal(t, StatusPanic, report.Node("op3").Status)
+	assert.False(t, report.Node("op3").Fallback)
+	assert.Equal(t, StatusSuccess, report.Node("op4").Status)
+	assert.False(t, report.Node("op4").Fallback)
+	assert.Equal(t, StatusSkipped, report.Node("op5").Status)
+
+	assert.Contains(t, dag.ExportDOT(), `"op1" [label="op1\n*godag.SimpleOp\ntimeout=10ms\ntimeout+fallback `)
+}
+
+func TestFallbackTimeout(t *testing.T) {
+	start := NewStartNode("start")
+	start.AddNextE("op1", &ErrOp{err: errors.New("boom")}).
+		WithTimeout(10 * time.Millisecond).WithFallback(&SimpleOp{data: "op1_slow", processTime: 50 * time.Millisecond})
+	start.AddNext("op2", &SimpleOp{data: "op2_data", processTime: time.Second}).
+		WithFallback(&SimpleOp{data: "op2_fallback"})
+	start.AddNextE("op3", &ErrOp{err: errors.New("boom")}).
+		WithFallback(&SimpleOp{data: "op3_slow", processTime: 300 * time.Millisecond})
+
+	// op1 falls back too slowly, op2 has no time left for its fallback, the
+	// fallback of op3 ignoring its ctx is abandoned at the run deadline
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	dag.WithTimeout(30 * time.Millisecond)
+	startTime := time.Now()
+	err := dag.Execute(context.Background())
+	assert.True(t, time.Since(startTime) < 150*time.Millisecond)
+	assert.True(t, errors.Is(err, ErrRunDeadline))
+	assert.ElementsMatch(t, []string{"op1", "op3"}, err.(*RunError).FailedIDs())
+	var fallbacks []string
+	for _, op := range dag.GetAbandonedOps() {
+		if op.Attempt == 0 {
+			fallbacks = append(fallbacks, op.ID)
+		}
+	}
+	assert.ElementsMatch(t, []string{"op1", "op3"}, fallbacks)
+
+	report := dag.GetReport()
+	assert.Equal(t, StatusFailed, report.Node("op1").Status)
+	assert.False(t, report.Node("op1").Fallback)
+	assert.Equal(t, StatusTimeout, report.Node("op2").Status)
+	assert.False(t, report.Node("op2").Fallback)
+	assert.False(t, report.Node("op3").Fallback)
+	assert.Nil(t, dag.GetStateKeeper().GetOutput("op1"))
+	assert.Nil(t, dag.GetStateKeeper().GetOutput("op2"))
+	assert.Nil(t, dag.GetStateKeeper().GetOutput("op3"))
+}
@@ -0,0 +1,82 @@
+package godag
+
+import (
+	"context"
+	"time"
+)
+
+// WithInline runs the op synchronously on the goroutine which made the node
+// ready (the one which finished its last parent), which is cheaper than a task
+// of the executor for trivial ops like projections.
+//
+// An inline op cannot be abandoned, so its timeout (and the run deadline or
+// cancellation) is enforced cooperatively: the context of the op is done at
+// the deadline, and an op returning after it is recorded as StatusTimeout
+// with its output dropped, but the run waits for the op to return.
+func (n *Node) WithInline() *Node {
+	n.inline = true
+	return n
+}
+
+// WithAutoInline inlines (see Node.WithInline) every node without timeout
+// which is the only child made ready by its parent, the op then runs on the
+// goroutine of its parent just like a function call
+func (p *DAG) WithAutoInline(enable bool) *DAG {
+	p.autoInline = enable
+	return p
+}
+
+// markInline decides which nodes made ready by the same parent run inline,
+// and puts them after the others so that the ops submitted to the executor
+// do not wait for the inline ones. Guarded by p.mu.
+func (p *DAG) markInline(ready []int) []int {
+	if p.autoInline && len(ready) == 1 {
+		node := p.tpl.nodes[ready[0]]
+		p.states[ready[0]].inline = node.timeout == 0 && node.retry.TotalTimeout == 0 && !node.mapped
+	}
+	for _, idx := range ready {
+		if p.tpl.nodes[idx].inline && !p.tpl.nodes[idx].mapped {
+			p.states[idx].inline = true
+		}
+	}
+	if len(ready) < 2 {
+		return ready
+	}
+	sorted := make([]int, 0, len(ready))
+	for _, idx := range ready {
+		if !p.states[idx].inline {
+			sorted = append(sorted, idx)
+		}
+	}
+	for _, idx := range ready {
+		if p.states[idx].inline {
+			sorted = append(sorted, idx)
+		}
+	}
+	return sorted
+}
+
+// isInline reports whether the op of node idx runs inline
+func (p *DAG) isInline(idx int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.states[idx].inline
+}
+
+// runInline runs an attempt of an inline op on the calling goroutine, an op
+// returning after its context is done gets ErrTimeout
+func (p *DAG) runInline(ctx context.Context, idx int, op Op, global interface{}, in Inputs) (interface{}, error) {
+	if ctx.Err() != nil {
+		return nil, ErrTimeout
+	}
+	p.mu.Lock()
+	if p.states[idx].startTime.IsZero() {
+		p.states[idx].startTime = time.Now()
+	}
+	p.mu.Unlock()
+	output, err := callOp(ctx, op, global, in)
+	if ctx.Err() != nil {
+		return nil, ErrTimeout
+	}
+	return output, err
+}
@@ -0,0 +1,92 @@
+package godag
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// CountingExecutor counts the tasks submitted
+type CountingExecutor struct {
+	tasks int32
+}
+
+func (e *CountingExecutor) Submit(task func()) {
+	atomic.AddInt32(&e.tasks, 1)
+	go task()
+}
+
+func TestInline(t *testing.T) {
+	/**
+	           |-> op1(inline) -> op2(inline) -> op3
+	    start->|
+	           |-> op4
+	**/
+	start := NewStartNode("start")
+	start.AddNext("op1", &ConcatOp{name: "op1"}).WithInline().
+		AddNext("op2", &ConcatOp{name: "op2"}).WithInline().
+		AddNext("op3", &ConcatOp{name: "op3"})
+	start.AddNext("op4", &ConcatOp{name: "op4"})
+
+	executor := &CountingExecutor{}
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	dag.WithExecutor(executor)
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&executor.tasks)) // op3 and op4
+	assert.Equal(t, "<nil>op3[<nil>op2[<nil>op1[<nil>]]]", dag.GetStateKeeper().GetOutput("op3"))
+
+	report := dag.GetReport()
+	assert.True(t, report.Node("op1").Inline)
+	assert.True(t, report.Node("op2").Inline)
+	assert.False(t, report.Node("op3").Inline)
+	assert.False(t, report.Node("op4").Inline)
+}
+
+func TestAutoInline(t *testing.T) {
+	/**
+	           |-> op1 -> op2 -> |
+	    start->|                 |-> op4
+	           |-> op3(timeout)->|
+	**/
+	start := NewStartNode("start")
+	op2 := start.AddNext("op1", &ConcatOp{name: "op1"}).AddNext("op2", &ConcatOp{name: "op2"})
+	op3 := start.AddNext("op3", &ConcatOp{name: "op3"}).WithTimeout(time.Second)
+	op4 := op2.AddNext("op4", &ConcatOp{name: "op4"})
+	op3.AddNextNode(op4)
+
+	executor := &CountingExecutor{}
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	dag.WithExecutor(executor).WithAutoInline(true)
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&executor.tasks)) // op1 and op3 are ready together
+
+	report := dag.GetReport()
+	assert.False(t, report.Node("op1").Inline)
+	assert.True(t, report.Node("op2").Inline)
+	assert.False(t, report.Node("op3").Inline)
+	assert.True(t, report.Node("op4").Inline) // the only child made ready by its last parent
+}
+
+func TestInlineTimeout(t *testing.T) {
+	start := NewStartNode("start")
+	// op1 ignores ctx, op2 honors it
+	start.AddNext("op1", &SimpleOp{data: "op1_data", processTime: 50 * time.Millisecond}).
+		WithInline().WithTimeout(10 * time.Millisecond)
+	start.AddNextE("op2", &CtxOp{}).WithInline().WithTimeout(10 * time.Millisecond)
+
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	startTime := time.Now()
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.True(t, time.Since(startTime) >= 50*time.Millisecond) // the run waits for op1
+	assert.Equal(t, StatusTimeout, dag.GetNodeStatus("op1"))
+	assert.Equal(t, StatusTimeout, dag.GetNodeStatus("op2"))
+	assert.Empty(t, dag.GetStateKeeper().GetAllOutput())
+	assert.Empty(t, dag.GetAbandonedOps())
+	assert.True(t, dag.GetReport().Node("op2").Duration < 40*time.Millisecond)
+}
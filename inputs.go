@@ -0,0 +1,213 @@
+package godag
+
+import (
+	"context"
+	"fmt"
+)
+
+// InputsOp is an op which reads its inputs by name or by parent id, so that
+// it does not depend on the order in which the parents were linked. Use
+// NewNodeI/AddNextI to create its node and WithInput to name the inputs.
+type InputsOp interface {
+	ProcessInputs(ctx context.Context, global interface{}, in Inputs) (interface{}, error)
+}
+
+// nodeInput names the input of a node fed by an output port of parent
+type nodeInput struct {
+	parent *Node
+	port   string // "" is the whole output
+	name   string
+}
+
+// tplInput is a nodeInput in a Template
+type tplInput struct {
+	prev int // index in prev of the parent
+	port string
+	name string
+}
+
+// WithInput links parent to n if not linked yet and names the input fed by
+// it, the op reads it by Inputs.Get(name). Two parents feeding the same
+// name is an error reported by Validate.
+func (n *Node) WithInput(name string, parent *Node) *Node {
+	return n.WithInputPort(name, parent, "")
+}
+
+// WithInputPort is the same as WithInput but the input is the output port
+// "port" of parent (see WithOutputs). A parent can feed several inputs of n
+// from different ports. If every input fed by parent reads the same port,
+// the positional input of parent (Inputs.At, the input of Op) is that port
+// too, otherwise it is the whole Outputs.
+func (n *Node) WithInputPort(name string, parent *Node, port string) *Node {
+	parent.AddNextNode(n)
+	n.inputs = append(n.inputs, nodeInput{parent: parent, port: port, name: name})
+	return n
+}
+
+// compileInputs resolves the named inputs of node i
+func (t *Template) compileInputs(i int, origin *Node) {
+	if len(origin.inputs) == 0 {
+		return
+	}
+	ports := make([]string, len(origin.prev))
+	mixed := make([]bool, len(origin.prev))
+	seen := make([]bool, len(origin.prev))
+	for _, input := range origin.inputs {
+		for j, parent := range origin.prev {
+			if parent != input.parent {
+				continue
+			}
+			if seen[j] && ports[j] != input.port {
+				mixed[j] = true
+			}
+			seen[j], ports[j] = true, input.port
+			if input.name != "" {
+				t.inputs[i] = append(t.inputs[i], tplInput{prev: j, port: input.port, name: input.name})
+			}
+		}
+	}
+	for j := range ports {
+		if mixed[j] {
+			ports[j] = ""
+		}
+	}
+	t.prevPorts[i] = ports
+}
+
+// readInputs reads the inputs of node idx from the state keeper, an error
+// is returned if a transform of the edges failed
+func (p *DAG) readInputs(idx int) (Inputs, error) {
+	prev := p.tpl.prev[idx]
+	ports := p.tpl.prevPorts[idx]
+	in := Inputs{tpl: p.tpl, idx: idx, values: make([]interface{}, len(prev))}
+	for i := range prev {
+		// NOTE: the order of prev will result the order of args passed to op
+		port := ""
+		if ports != nil {
+			port = ports[i]
+		}
+		v, err := p.readInput(idx, i, port) // will get the parent output as input of current
+		if err != nil {
+			return in, err
+		}
+		in.values[i] = v
+	}
+	if inputs := p.tpl.inputs[idx]; len(inputs) > 0 {
+		in.named = make([]interface{}, len(inputs))
+		for k, input := range inputs {
+			if input.port == ports[input.prev] {
+				in.named[k] = in.values[input.prev]
+				continue
+			}
+			v, err := p.readInput(idx, input.prev, input.port)
+			if err != nil {
+				return in, err
+			}
+			in.named[k] = v
+		}
+	}
+	return in, nil
+}
+
+// Inputs are the outputs of the parents of a node passed to its op
+type Inputs struct {
+	tpl    *Template
+	idx    int
+	values []interface{} // in the order of prev
+	named  []interface{} // in the order of tpl.inputs[idx]
+}
+
+// Len returns the number of inputs
+func (in Inputs) Len() int {
+	return len(in.values)
+}
+
+// At returns the input i in the order of prev, the same as input[i] of Op
+func (in Inputs) At(i int) interface{} {
+	return in.values[i]
+}
+
+// Values returns the inputs in the order of prev
+func (in Inputs) Values() []interface{} {
+	return in.values
+}
+
+// Get returns the input named name, nil if there is no such input
+func (in Inputs) Get(name string) interface{} {
+	v, _ := in.Lookup(name)
+	return v
+}
+
+// Lookup returns the input named name and whether it exists
+func (in Inputs) Lookup(name string) (interface{}, bool) {
+	if in.tpl == nil {
+		return nil, false
+	}
+	for k, input := range in.tpl.inputs[in.idx] {
+		if input.name == name {
+			return in.named[k], true
+		}
+	}
+	return nil, false
+}
+
+// From returns the input fed by the parent parentID, nil if there is no such parent
+func (in Inputs) From(parentID string) interface{} {
+	if in.tpl == nil {
+		return nil
+	}
+	for i, parent := range in.tpl.prev[in.idx] {
+		if in.tpl.nodes[parent].id == parentID {
+			return in.values[i]
+		}
+	}
+	return nil
+}
+
+// inputsAdapter lets an InputsOp be stored as the Op of a node
+type inputsAdapter struct {
+	InputsOp
+}
+
+func (a inputsAdapter) Process(ctx context.Context, global interface{}, input ...interface{}) interface{} {
+	output, _ := a.ProcessInputs(ctx, global, Inputs{values: input})
+	return output
+}
+
+// checkInputs returns the named inputs of nodes which conflict with each
+// other or read an undeclared output port
+func checkInputs(nodes []*Node) []string {
+	type output struct {
+		parent *Node
+		port   string
+	}
+	var conflicts []string
+	for _, n := range nodes {
+		names := make(map[string]output)
+		outputs := make(map[output]string)
+		for _, input := range n.inputs {
+			key := output{parent: input.parent, port: input.port}
+			if input.port != "" && !input.parent.hasPort(input.port) {
+				conflicts = append(conflicts,
+					fmt.Sprintf("input %q of node %q reads undeclared port %q", input.name, n.id, portID(input.parent.id, input.port)))
+			}
+			if input.name == "" { // positional only
+				continue
+			}
+			if other, ok := names[input.name]; ok && other != key {
+				conflicts = append(conflicts,
+					fmt.Sprintf("input %q of node %q is fed by both %q and %q",
+						input.name, n.id, portID(other.parent.id, other.port), portID(input.parent.id, input.port)))
+				continue
+			}
+			names[input.name] = key
+			if other, ok := outputs[key]; ok && other != input.name {
+				conflicts = append(conflicts,
+					fmt.Sprintf("node %q feeds both inputs %q and %q of node %q", portID(input.parent.id, input.port), other, input.name, n.id))
+				continue
+			}
+			outputs[key] = input.name
+		}
+	}
+	return conflicts
+}
@@ -0,0 +1,97 @@
+package godag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// JoinOp joins its inputs named by names
+type JoinOp struct {
+	names []string
+}
+
+func (o *JoinOp) ProcessInputs(ctx context.Context, global interface{}, in Inputs) (interface{}, error) {
+	output := ""
+	for _, name := range o.names {
+		v, ok := in.Lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("no input %q", name)
+		}
+		output += fmt.Sprintf("%s=%v;", name, v)
+	}
+	return output, nil
+}
+
+func TestNamedInputs(t *testing.T) {
+	// the same graph as TestLeftJoin, the inputs of ds_left_join are named
+	// rather than ordered by InsertPrevNode
+	start := NewStartNode("start")
+	ds1 := start.AddNext("ds1", &SimpleOp{data: "mock_aikan_play"})
+	ds2 := start.AddNext("ds2", &SimpleOp{data: "mock_aikan_insert"})
+	ds3 := start.AddNext("ds3", &SimpleOp{data: "mock_aikan_exposure"})
+	ds4 := start.AddNext("ds4", &SimpleOp{data: "mock_aikan_exposure2"})
+	ds_all_play := ds1.AddNext("ds_all_play", &SimpleOp{data: "ds_all_play"})
+	ds2.AddNextNode(ds_all_play)
+	ds_left_join := ds3.AddNextI("ds_left_join", &JoinOp{names: []string{"left", "right", "extra"}}).
+		WithInput("right", ds_all_play).
+		WithInput("left", ds4).
+		WithInput("extra", ds3)
+
+	assert.Equal(t, 3, ds_left_join.indegree)
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, "left=mock_aikan_exposure2;right=ds_all_play;extra=mock_aikan_exposure;",
+		dag.GetStateKeeper().GetOutput("ds_left_join"))
+}
+
+func TestInputsAccess(t *testing.T) {
+	start := NewStartNode("start")
+	op1 := start.AddNext("op1", &SimpleOp{data: "op1_data"})
+	op2 := start.AddNext("op2", &SimpleOp{data: "op2_data"})
+	var got Inputs
+	op3 := NewNodeI("op3", inputsFunc(func(ctx context.Context, global interface{}, in Inputs) (interface{}, error) {
+		got = in
+		return nil, nil
+	})).WithInput("a", op2)
+	op1.AddNextNode(op3) // unnamed
+
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, 2, got.Len())
+	assert.Equal(t, []interface{}{"op2_data", "op1_data"}, got.Values())
+	assert.Equal(t, "op1_data", got.At(1))
+	assert.Equal(t, "op2_data", got.Get("a"))
+	assert.Equal(t, "op1_data", got.From("op1"))
+	assert.Nil(t, got.From("start"))
+	_, ok := got.Lookup("b")
+	assert.False(t, ok)
+}
+
+func TestInputConflicts(t *testing.T) {
+	start := NewStartNode("start")
+	op1 := start.AddNext("op1", &SimpleOp{data: "op1_data"})
+	op2 := start.AddNext("op2", &SimpleOp{data: "op2_data"})
+	NewNodeI("op3", &JoinOp{}).WithInput("a", op1).WithInput("a", op2).WithInput("b", op1)
+
+	err := Validate(start)
+	var ve *ValidationError
+	assert.True(t, errors.As(err, &ve))
+	assert.Equal(t, []string{
+		`input "a" of node "op3" is fed by both "op1" and "op2"`,
+		`node "op1" feeds both inputs "a" and "b" of node "op3"`,
+	}, ve.InputConflicts)
+	_, err = Compile(start)
+	assert.Error(t, err)
+}
+
+type inputsFunc func(ctx context.Context, global interface{}, in Inputs) (interface{}, error)
+
+func (f inputsFunc) ProcessInputs(ctx context.Context, global interface{}, in Inputs) (interface{}, error) {
+	return f(ctx, global, in)
+}
@@ -0,0 +1,220 @@
+package godag
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// MapIndex is the context key of the index of the element processed by an
+// instance of a map node, get it by ctx.Value(StateKey(MapIndex)).(int)
+const MapIndex = "__mapIndex__"
+
+// MapResult is the output of a map node, see Node.WithMap
+type MapResult struct {
+	Outputs []interface{} // output of each element in order, nil if it failed
+	Errors  []error       // error of each element in order, nil if it succeeded
+}
+
+// Failed returns the number of elements which failed
+func (r *MapResult) Failed() int {
+	failed := 0
+	for _, err := range r.Errors {
+		if err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// WithMap links parent to n if not linked yet and makes n a map node: once
+// its parents finished, the op of n runs once per element of the input from
+// parent, which should be a slice, with the element in place of that input
+// (the positional one and the named ones reading the same output, see
+// WithInput) and the other inputs unchanged. At most maxParallel instances
+// run at the same time, no limit if maxParallel <= 0. Each instance has the
+// timeout and the retries of n.
+//
+// The output of n is a *MapResult, a failed element does not fail n but is
+// recorded in MapResult.Errors for the reduce node (see AddReduce). Each
+// instance is shown in NodeReport.Instances, NodeReport.Attempts is the most
+// attempts of an instance. A map node never runs inline.
+func (n *Node) WithMap(parent *Node, maxParallel int) *Node {
+	parent.AddNextNode(n)
+	n.mapped = true
+	n.mapFrom = parent
+	n.maxParallel = maxParallel
+	return n
+}
+
+// ReduceFunc reduces the results of a map node, r is empty if the map node
+// gave no output (e.g. it failed under ContinueAll)
+type ReduceFunc func(ctx context.Context, global interface{}, r *MapResult) (interface{}, error)
+
+// AddReduce adds a node reducing the results of the map node n
+func (n *Node) AddReduce(id string, fn ReduceFunc) *Node {
+	return n.AddNextE(id, reduceOp(fn))
+}
+
+type reduceOp ReduceFunc
+
+func (o reduceOp) ProcessE(ctx context.Context, global interface{}, input ...interface{}) (interface{}, error) {
+	r := &MapResult{}
+	if len(input) > 0 && input[0] != nil {
+		var ok bool
+		if r, ok = input[0].(*MapResult); !ok {
+			return nil, fmt.Errorf("godag: reduce needs the output of a map node, got %T", input[0])
+		}
+	}
+	return o(ctx, global, r)
+}
+
+// InstanceReport is the execution report of an instance of a map node
+type InstanceReport struct {
+	Index     int           `json:"index"` // index of the element
+	Status    NodeStatus    `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	Attempts  int           `json:"attempts"`
+	StartTime time.Time     `json:"start_time"` // when the instance was submitted
+	EndTime   time.Time     `json:"end_time"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// instanceState is the state of an instance of a map node in one run
+type instanceState struct {
+	status    NodeStatus
+	err       error
+	attempts  int
+	startTime time.Time
+	endTime   time.Time
+}
+
+// runMap runs the op of the map node idx once per element of the input from
+// its map parent and calls done with the *MapResult
+func (p *DAG) runMap(ctx context.Context, idx int, global interface{}, in Inputs, done func(output interface{}, err error)) {
+	node := p.tpl.nodes[idx]
+	from := p.tpl.mapPrev[idx]
+	var elements reflect.Value
+	if in.values[from] != nil {
+		elements = reflect.ValueOf(in.values[from])
+	}
+	if elements.IsValid() && elements.Kind() != reflect.Slice && elements.Kind() != reflect.Array {
+		done(nil, fmt.Errorf("godag: map node %q needs a slice input, got %T", node.id, in.values[from]))
+		return
+	}
+	n := 0
+	if elements.IsValid() {
+		n = elements.Len()
+	}
+	result := &MapResult{Outputs: make([]interface{}, n), Errors: make([]error, n)}
+	p.mu.Lock()
+	p.states[idx].instances = make([]instanceState, n)
+	if p.states[idx].startTime.IsZero() {
+		p.states[idx].startTime = time.Now()
+	}
+	p.mu.Unlock()
+	if n == 0 {
+		done(result, nil)
+		return
+	}
+	parallel := node.maxParallel
+	if parallel <= 0 || parallel > n {
+		parallel = n
+	}
+
+	next, finished := 0, 0
+	var start func(i int)
+	// launch returns the next element to start, -1 if none
+	launch := func() int {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if next == n {
+			return -1
+		}
+		next++
+		p.states[idx].instances[next-1].startTime = time.Now()
+		return next - 1
+	}
+	start = func(i int) {
+		elemIn := p.elementInputs(idx, in, elements.Index(i).Interface())
+		elemCtx := context.WithValue(ctx, StateKey(MapIndex), i)
+		p.retryOp(elemCtx, idx, global, elemIn, func(attempt int) {
+			p.mu.Lock()
+			p.states[idx].instances[i].attempts = attempt
+			if attempt > p.states[idx].attempts {
+				p.states[idx].attempts = attempt
+			}
+			p.mu.Unlock()
+		}, func(output interface{}, err error) {
+			status := instanceStatus(ctx, err)
+			p.mu.Lock()
+			instance := &p.states[idx].instances[i]
+			instance.status, instance.err, instance.endTime = status, err, time.Now()
+			if err == nil {
+				result.Outputs[i] = output
+			} else {
+				result.Errors[i] = err
+			}
+			finished++
+			last := finished == n
+			p.mu.Unlock()
+			if last {
+				if ctx.Err() != nil {
+					done(nil, ErrTimeout)
+				} else {
+					done(result, nil)
+				}
+				return
+			}
+			if j := launch(); j >= 0 {
+				start(j)
+			}
+		})
+	}
+	for k := 0; k < parallel; k++ {
+		if i := launch(); i >= 0 {
+			start(i)
+		}
+	}
+}
+
+// elementInputs returns the inputs of an instance of the map node idx, the
+// input from the map parent and the named ones reading the same output are elem
+func (p *DAG) elementInputs(idx int, in Inputs, elem interface{}) Inputs {
+	from := p.tpl.mapPrev[idx]
+	port := ""
+	if ports := p.tpl.prevPorts[idx]; ports != nil {
+		port = ports[from]
+	}
+	elemIn := in
+	elemIn.values = make([]interface{}, len(in.values))
+	copy(elemIn.values, in.values)
+	elemIn.values[from] = elem
+	if len(in.named) > 0 {
+		elemIn.named = make([]interface{}, len(in.named))
+		copy(elemIn.named, in.named)
+		for k, input := range p.tpl.inputs[idx] {
+			if input.prev == from && input.port == port {
+				elemIn.named[k] = elem
+			}
+		}
+	}
+	return elemIn
+}
+
+// instanceStatus is the status of an instance which returned err
+func instanceStatus(ctx context.Context, err error) NodeStatus {
+	switch {
+	case err == nil:
+		return StatusSuccess
+	case ctx.Err() != nil:
+		return StatusCanceled
+	case err == ErrTimeout:
+		return StatusTimeout
+	}
+	if _, ok := err.(*PanicError); ok {
+		return StatusPanic
+	}
+	return StatusFailed
+}
@@ -0,0 +1,150 @@
+package godag
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// LookupOp looks up an element, "bad" elements fail
+type LookupOp struct {
+	running, maxRunning int32
+}
+
+func (o *LookupOp) ProcessE(ctx context.Context, global interface{}, input ...interface{}) (interface{}, error) {
+	running := atomic.AddInt32(&o.running, 1)
+	defer atomic.AddInt32(&o.running, -1)
+	for {
+		max := atomic.LoadInt32(&o.maxRunning)
+		if running <= max || atomic.CompareAndSwapInt32(&o.maxRunning, max, running) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	elem := input[0].(string)
+	if elem == "bad" {
+		return nil, errors.New("bad element")
+	}
+	return elem + "@" + input[1].(string) + "#" + string(rune('0'+ctx.Value(StateKey(MapIndex)).(int))), nil
+}
+
+// gather joins the outputs and counts the errors
+func gather(ctx context.Context, global interface{}, r *MapResult) (interface{}, error) {
+	outputs := make([]string, len(r.Outputs))
+	for i, output := range r.Outputs {
+		if r.Errors[i] != nil {
+			outputs[i] = "-"
+			continue
+		}
+		outputs[i] = output.(string)
+	}
+	return strings.Join(outputs, ","), nil
+}
+
+func TestMap(t *testing.T) {
+	/**
+	           |-> videos ->|
+	    start->|            |-> lookup(map) -> reduce
+	           |-> region ->|
+	**/
+	start := NewStartNode("start")
+	op := &LookupOp{}
+	videos := start.AddNext("videos", &ValueOp{value: []string{"v1", "v2", "bad", "v4", "v5"}})
+	lookup := videos.AddNextE("lookup", op).WithMap(videos, 2)
+	start.AddNext("region", &ValueOp{value: "cn"}).AddNextNode(lookup)
+	lookup.AddReduce("reduce", gather)
+
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&op.maxRunning))
+	assert.Equal(t, "v1@cn#0,v2@cn#1,-,v4@cn#3,v5@cn#4", dag.GetStateKeeper().GetOutput("reduce"))
+	r := dag.GetStateKeeper().GetOutput("lookup").(*MapResult)
+	assert.Equal(t, 1, r.Failed())
+	assert.EqualError(t, r.Errors[2], "bad element")
+
+	report := dag.GetReport().Node("lookup")
+	assert.Equal(t, StatusSuccess, report.Status)
+	assert.Equal(t, 1, report.Attempts)
+	assert.Len(t, report.Instances, 5)
+	for i, instance := range report.Instances {
+		assert.Equal(t, i, instance.Index)
+		assert.Equal(t, 1, instance.Attempts)
+		assert.True(t, instance.Duration >= 10*time.Millisecond)
+		if i == 2 {
+			assert.Equal(t, StatusFailed, instance.Status)
+			assert.Equal(t, "bad element", instance.Error)
+		} else {
+			assert.Equal(t, StatusSuccess, instance.Status)
+		}
+	}
+	assert.True(t, report.Duration >= 30*time.Millisecond) // 5 elements, 2 at a time
+}
+
+func TestMapNamed(t *testing.T) {
+	/**
+	           |-> region ->|
+	    start->|            |-> lookup(map by name) -> reduce
+	           |-> videos ->|
+	**/
+	start := NewStartNode("start")
+	region := start.AddNext("region", &ValueOp{value: "cn"})
+	videos := start.AddNext("videos", &ValueOp{value: []string{"v1", "v2"}})
+	lookup := NewNodeI("lookup", inputsFunc(func(ctx context.Context, global interface{}, in Inputs) (interface{}, error) {
+		return in.Get("video").(string) + "@" + in.Get("region").(string), nil
+	})).WithInput("region", region).WithInput("video", videos).WithMap(videos, 0)
+	lookup.AddReduce("reduce", gather)
+
+	// videos is not the first parent, its element replaces the named input
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, "v1@cn,v2@cn", dag.GetStateKeeper().GetOutput("reduce"))
+}
+
+func TestMapInput(t *testing.T) {
+	for _, c := range []struct {
+		input  interface{}
+		status NodeStatus
+		output interface{}
+	}{
+		{[]string{}, StatusSuccess, ""},
+		{nil, StatusSuccess, ""},
+		{"not a slice", StatusFailed, nil},
+	} {
+		start := NewStartNode("start")
+		videos := start.AddNext("videos", &ValueOp{value: c.input})
+		videos.AddNextE("lookup", &LookupOp{}).WithMap(videos, 0).
+			AddReduce("reduce", gather)
+
+		var dag DAG
+		assert.NoError(t, dag.Init(start, nil))
+		dag.Execute(context.Background())
+		assert.Equal(t, c.status, dag.GetNodeStatus("lookup"))
+		assert.Equal(t, c.output, dag.GetStateKeeper().GetOutput("reduce"))
+	}
+}
+
+func TestMapTimeout(t *testing.T) {
+	start := NewStartNode("start")
+	videos := start.AddNext("videos", &ValueOp{value: []int{1, 2, 3}})
+	videos.AddNextE("lookup", &CtxOp{}).WithMap(videos, 0).WithTimeout(10*time.Millisecond).
+		AddReduce("reduce", func(ctx context.Context, global interface{}, r *MapResult) (interface{}, error) {
+			return r.Failed(), nil
+		})
+
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	startTime := time.Now()
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.True(t, time.Since(startTime) < 50*time.Millisecond) // the instances run in parallel
+	assert.Equal(t, 3, dag.GetStateKeeper().GetOutput("reduce"))
+	for _, instance := range dag.GetReport().Node("lookup").Instances {
+		assert.Equal(t, StatusTimeout, instance.Status)
+	}
+}
@@ -1,16 +1,42 @@
 package godag
 
-import "time"
+import (
+	"context"
+	"runtime/debug"
+	"time"
+)
 
+// Node is used to build the graph, the graph is frozen into a Template before execution
 type Node struct {
-	id         string // id should be unique
-	op         Op
-	prev       []*Node
-	next       []*Node
-	timeout    time.Duration
-	isCanceled bool
-	indegree   int
-	costTime   time.Duration
+	id           string // id should be unique
+	op           Op
+	prev         []*Node
+	next         []*Node
+	timeout      time.Duration
+	retry        RetryPolicy
+	fallback     *nodeFallback
+	expected     time.Duration // declared duration used for deadline budgeting
+	resources    []resourceClaim
+	priority     int64                // used by SchedulePriority
+	inline       bool                 // set by WithInline
+	inputs       []nodeInput          // named inputs, set by WithInput
+	ports        []string             // output ports, set by WithOutputs
+	transforms   map[*Node]*Transform // parent => transform of the edge, set by WithTransform
+	condition    Condition            // set by WithCondition
+	switcher     bool                 // set by WithSwitch
+	trigger      TriggerRule          // set by WithTrigger
+	quorum       int                  // k of TriggerQuorum
+	cancelSlower bool                 // set by WithFirstSuccess
+	mapped       bool                 // set by WithMap
+	mapFrom      *Node                // parent giving the elements of the map node
+	maxParallel  int                  // instances of the map node running at the same time
+	indegree     int
+}
+
+// fallback is either an op or a static value
+type nodeFallback struct {
+	op    Op
+	value interface{}
 }
 
 func NewStartNode(id string) *Node {
@@ -26,11 +52,55 @@ func NewNode(id string, op Op) *Node {
 	}
 }
 
+// NewNodeE creates a node whose op can fail
+func NewNodeE(id string, op OpE) *Node {
+	return NewNode(id, opEAdapter{op})
+}
+
+// NewNodeI creates a node whose op reads its inputs by name, see WithInput
+func NewNodeI(id string, op InputsOp) *Node {
+	return NewNode(id, inputsAdapter{op})
+}
+
 func (n *Node) WithTimeout(timeout time.Duration) *Node {
 	n.timeout = timeout
 	return n
 }
 
+// WithRetry retries the op on failure, the timeout of the node applies to each attempt
+func (n *Node) WithRetry(policy RetryPolicy) *Node {
+	n.retry = policy
+	return n
+}
+
+// WithExpectedDuration declares how long the op usually takes, which is used
+// to budget the run deadline (see DAG.WithDeadlineBudget) instead of the
+// durations observed by the previous runs
+func (n *Node) WithExpectedDuration(d time.Duration) *Node {
+	n.expected = d
+	return n
+}
+
+// WithFallback degrades the node when its op times out, fails or panics (after
+// retries if any). fallback can be an Op or OpE run with the same input, or a
+// static value used as the output. The run report records that the fallback was used.
+// A fallback op runs as an attempt 0 of the node: it has the timeout of the
+// node and is capped by the run deadline, if it does not return in time it is
+// abandoned (see DAG.GetAbandonedOps) and the fallback is not used.
+func (n *Node) WithFallback(fallback interface{}) *Node {
+	switch f := fallback.(type) {
+	case InputsOp:
+		n.fallback = &nodeFallback{op: inputsAdapter{f}}
+	case OpE:
+		n.fallback = &nodeFallback{op: opEAdapter{f}}
+	case Op:
+		n.fallback = &nodeFallback{op: f}
+	default:
+		n.fallback = &nodeFallback{value: f}
+	}
+	return n
+}
+
 func (n *Node) AddNext(id string, op Op) *Node {
 	newNode := Node{
 		id:       id,
@@ -42,6 +112,16 @@ func (n *Node) AddNext(id string, op Op) *Node {
 	return &newNode
 }
 
+// AddNextE is the same as AddNext but with an op which can fail
+func (n *Node) AddNextE(id string, op OpE) *Node {
+	return n.AddNext(id, opEAdapter{op})
+}
+
+// AddNextI is the same as AddNext but with an op which reads its inputs by name
+func (n *Node) AddNextI(id string, op InputsOp) *Node {
+	return n.AddNext(id, inputsAdapter{op})
+}
+
 func (n *Node) AddNextNode(node *Node) *Node {
 	for i := range n.next {
 		if n.next[i] == node { // already added
@@ -101,4 +181,31 @@ func (n *Node) FindPrev(nodes... *Node) *Node {
 		}
 	}
 	return nil
-}
\ No newline at end of file
+}
+
+// callOp invokes op, preferring ProcessE if op implements OpE, a panic in op
+// is recovered and returned as *PanicError
+func callOp(ctx context.Context, op Op, global interface{}, in Inputs) (output interface{}, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			output, err = nil, &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	if a, ok := op.(inputsAdapter); ok {
+		return a.ProcessInputs(ctx, global, in)
+	}
+	if e, ok := op.(OpE); ok {
+		return e.ProcessE(ctx, global, in.values...)
+	}
+	return op.Process(ctx, global, in.values...), nil
+}
+
+// opEAdapter lets an OpE be stored as the Op of a node
+type opEAdapter struct {
+	OpE
+}
+
+func (a opEAdapter) Process(ctx context.Context, global interface{}, input ...interface{}) interface{} {
+	output, _ := a.ProcessE(ctx, global, input...)
+	return output
+}
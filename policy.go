@@ -0,0 +1,16 @@
+package godag
+
+// FailurePolicy decides what happens to the rest of the DAG once a node fails
+type FailurePolicy int
+
+const (
+	// SkipDescendants skips every node depending on a failed node, the other
+	// branches keep running. This is the default policy.
+	SkipDescendants FailurePolicy = iota
+	// FailFast cancels the context shared by the run and skips every node
+	// which is not started yet.
+	FailFast
+	// ContinueAll runs every node, children of a failed node get nil as the
+	// input from it.
+	ContinueAll
+)
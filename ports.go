@@ -0,0 +1,69 @@
+package godag
+
+import (
+	"fmt"
+)
+
+// Outputs is the output of an op with named output ports (see WithOutputs),
+// port name => output of the port
+type Outputs map[string]interface{}
+
+// WithOutputs declares the output ports of n. The op of n returns Outputs,
+// each port is saved in the state keeper under PortKey(id, port) besides the
+// whole Outputs under id, and a child reads a port by WithInputPort.
+func (n *Node) WithOutputs(ports ...string) *Node {
+	n.ports = append(n.ports, ports...)
+	return n
+}
+
+// PortKey is the key of the output port "port" of node "id" in the state keeper
+func PortKey(id string, port string) string {
+	return id + ":" + port
+}
+
+// portID is the id of node "id" qualified by its output port if any, used in messages
+func portID(id string, port string) string {
+	if port == "" {
+		return id
+	}
+	return PortKey(id, port)
+}
+
+func (n *Node) hasPort(port string) bool {
+	for _, p := range n.ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+// checkOutputs checks that the output of an op with output ports is Outputs
+// of the declared ports
+func (n *Node) checkOutputs(output interface{}) error {
+	if len(n.ports) == 0 {
+		return nil
+	}
+	outputs, ok := output.(Outputs)
+	if !ok {
+		return fmt.Errorf("godag: node %q declares output ports but its op returned %T", n.id, output)
+	}
+	for port := range outputs {
+		if !n.hasPort(port) {
+			return fmt.Errorf("godag: node %q returned undeclared output port %q", n.id, port)
+		}
+	}
+	return nil
+}
+
+// saveOutput saves the output of node, and each output port if declared
+func (p *DAG) saveOutput(node *Node, output interface{}) {
+	p.stateKeeper.SetOutput(node.id, output)
+	if len(node.ports) == 0 {
+		return
+	}
+	outputs, _ := output.(Outputs) // a static fallback value leaves the ports nil
+	for _, port := range node.ports {
+		p.stateKeeper.SetOutput(PortKey(node.id, port), outputs[port])
+	}
+}
@@ -0,0 +1,73 @@
+package godag
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// SplitOp splits its input into the ports "train" and "eval"
+type SplitOp struct{}
+
+func (o SplitOp) Process(ctx context.Context, global interface{}, input ...interface{}) interface{} {
+	s := input[0].(string)
+	return Outputs{"train": s[:len(s)/2], "eval": s[len(s)/2:]}
+}
+
+func TestOutputPorts(t *testing.T) {
+	/**
+	                          |-(train)-> fit ---------->|
+	    start -> data -> split|                          |-> report
+	                          |-(eval)------------------>|
+	                          |-(train, eval)-> sizes
+	**/
+	start := NewStartNode("start")
+	data := start.AddNext("data", &SimpleOp{data: "aaaabb"})
+	split := data.AddNext("split", SplitOp{}).WithOutputs("train", "eval")
+	fit := NewNode("fit", &ConcatOp{name: "fit"}).WithInputPort("", split, "train")
+	NewNodeI("report", &JoinOp{names: []string{"model", "eval"}}).
+		WithInput("model", fit).
+		WithInputPort("eval", split, "eval")
+	var sizes Inputs
+	NewNodeI("sizes", inputsFunc(func(ctx context.Context, global interface{}, in Inputs) (interface{}, error) {
+		sizes = in
+		return nil, nil
+	})).WithInputPort("train", split, "train").WithInputPort("eval", split, "eval")
+
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	assert.NoError(t, dag.Execute(context.Background()))
+	sk := dag.GetStateKeeper()
+	assert.Equal(t, "aaa", sk.GetOutput(PortKey("split", "train")))
+	assert.Equal(t, "abb", sk.GetOutput(PortKey("split", "eval")))
+	assert.Equal(t, Outputs{"train": "aaa", "eval": "abb"}, sk.GetOutput("split"))
+	assert.Contains(t, sk.GetAllOutput(), "split:train")
+	assert.Equal(t, "<nil>fit[aaa]", sk.GetOutput("fit"))
+	assert.Equal(t, "model=<nil>fit[aaa];eval=abb;", sk.GetOutput("report"))
+	assert.Equal(t, "aaa", sizes.Get("train"))
+	assert.Equal(t, "abb", sizes.Get("eval"))
+	assert.Equal(t, Outputs{"train": "aaa", "eval": "abb"}, sizes.At(0)) // mixed ports, the whole output
+}
+
+func TestOutputPortsInvalid(t *testing.T) {
+	start := NewStartNode("start")
+	split := start.AddNext("split", &SimpleOp{data: "not outputs"}).WithOutputs("train")
+	NewNode("fit", &ConcatOp{name: "fit"}).WithInputPort("", split, "test")
+
+	err := Validate(start)
+	var ve *ValidationError
+	assert.True(t, errors.As(err, &ve))
+	assert.Equal(t, []string{`input "" of node "fit" reads undeclared port "split:test"`}, ve.InputConflicts)
+
+	start = NewStartNode("start")
+	split = start.AddNext("split", &SimpleOp{data: "not outputs"}).WithOutputs("train")
+	NewNode("fit", &ConcatOp{name: "fit"}).WithInputPort("", split, "train")
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	assert.Error(t, dag.Execute(context.Background()))
+	assert.Equal(t, StatusFailed, dag.GetNodeStatus("split"))
+	assert.Equal(t, `godag: node "split" declares output ports but its op returned string`, dag.GetReport().Node("split").Error)
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("fit"))
+}
@@ -0,0 +1,100 @@
+package godag
+
+import (
+	"time"
+)
+
+// Report is the execution report of a run, it can be marshaled to JSON
+type Report struct {
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
+	WallTime  time.Duration `json:"wall_time"` // from Execute started to returned
+	Nodes     []NodeReport  `json:"nodes"`     // in the order of Template
+}
+
+// NodeReport is the execution report of a node in a run
+type NodeReport struct {
+	ID        string        `json:"id"`
+	Prev      []string      `json:"prev,omitempty"` // id of the parents
+	Status    NodeStatus    `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	Fallback  bool          `json:"fallback,omitempty"` // the output is from the fallback
+	Inline    bool          `json:"inline,omitempty"`   // the op ran inline, see Node.WithInline
+	Attempts  int           `json:"attempts"`
+	ReadyTime time.Time     `json:"ready_time"` // when all the parents finished
+	StartTime time.Time     `json:"start_time"` // when the op started
+	EndTime   time.Time     `json:"end_time"`   // when the op finished
+	QueueWait time.Duration `json:"queue_wait"` // from ready to started, excluding ResourceWait
+	Duration  time.Duration `json:"duration"`   // from started to finished, including retries
+
+	ResourceWait time.Duration    `json:"resource_wait,omitempty"` // waiting for the resources of the node, see Node.WithResource
+	Instances    []InstanceReport `json:"instances,omitempty"`     // instances of a map node, see Node.WithMap
+}
+
+// GetReport returns the execution report of the run
+func (d *DAG) GetReport() *Report {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	r := &Report{
+		StartTime: d.startTime,
+		EndTime:   d.endTime,
+		Nodes:     make([]NodeReport, len(d.states)),
+	}
+	if !d.endTime.IsZero() {
+		r.WallTime = d.endTime.Sub(d.startTime)
+	}
+	for i := range d.states {
+		state := &d.states[i]
+		n := NodeReport{
+			ID:        d.tpl.nodes[i].id,
+			Prev:      make([]string, len(d.tpl.prev[i])),
+			Status:    state.status,
+			Fallback:  state.fallback,
+			Inline:    state.inline,
+			Attempts:  state.attempts,
+			ReadyTime: state.readyTime,
+			StartTime: state.startTime,
+			EndTime:   state.endTime,
+			Duration:  state.costTime(),
+
+			ResourceWait: state.resourceWait,
+		}
+		for j, parent := range d.tpl.prev[i] {
+			n.Prev[j] = d.tpl.nodes[parent].id
+		}
+		if state.err != nil && state.status != StatusSuccess {
+			n.Error = state.err.Error()
+		}
+		for j, instance := range state.instances {
+			ir := InstanceReport{
+				Index:     j,
+				Status:    instance.status,
+				Attempts:  instance.attempts,
+				StartTime: instance.startTime,
+				EndTime:   instance.endTime,
+			}
+			if instance.err != nil {
+				ir.Error = instance.err.Error()
+			}
+			if !instance.endTime.IsZero() {
+				ir.Duration = instance.endTime.Sub(instance.startTime)
+			}
+			n.Instances = append(n.Instances, ir)
+		}
+		if !state.readyTime.IsZero() && !state.startTime.IsZero() {
+			n.QueueWait = state.startTime.Sub(state.readyTime) - state.resourceWait
+		}
+		r.Nodes[i] = n
+	}
+	return r
+}
+
+// Node returns the report of node "id", nil if not found
+func (r *Report) Node(id string) *NodeReport {
+	for i := range r.Nodes {
+		if r.Nodes[i].ID == id {
+			return &r.Nodes[i]
+		}
+	}
+	return nil
+}
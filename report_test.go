@@ -0,0 +1,56 @@
+package godag
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReport(t *testing.T) {
+	start := NewStartNode("start")
+	op1 := start.AddNext("op1", &SimpleOp{data: "op1_data", processTime: 20 * time.Millisecond})
+	op1.AddNextE("op2", &FlakyOp{okAttempt: 2}).WithRetry(RetryPolicy{MaxAttempts: 2})
+	start.AddNext("op3", &SimpleOp{data: "op3_data", processTime: time.Second}).WithTimeout(10 * time.Millisecond)
+	start.AddNextE("op4", &ErrOp{err: errors.New("boom")}).AddNext("op5", &SimpleOp{data: "op5_data"})
+
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	assert.Error(t, dag.Execute(context.Background()))
+
+	report := dag.GetReport()
+	assert.Equal(t, 6, len(report.Nodes))
+	assert.True(t, report.WallTime >= 20*time.Millisecond)
+	assert.Equal(t, report.EndTime.Sub(report.StartTime), report.WallTime)
+
+	op1Report := report.Node("op1")
+	assert.Equal(t, StatusSuccess, op1Report.Status)
+	assert.Equal(t, 1, op1Report.Attempts)
+	assert.True(t, op1Report.Duration >= 20*time.Millisecond)
+	assert.Equal(t, op1Report.EndTime.Sub(op1Report.StartTime), op1Report.Duration)
+	assert.Equal(t, op1Report.StartTime.Sub(op1Report.ReadyTime), op1Report.QueueWait)
+
+	op2Report := report.Node("op2")
+	assert.Equal(t, StatusSuccess, op2Report.Status)
+	assert.Equal(t, 2, op2Report.Attempts)
+	assert.False(t, op2Report.ReadyTime.Before(op1Report.EndTime))
+
+	assert.Equal(t, StatusTimeout, report.Node("op3").Status)
+	assert.Equal(t, ErrTimeout.Error(), report.Node("op3").Error)
+	assert.Equal(t, StatusFailed, report.Node("op4").Status)
+	assert.Equal(t, "boom", report.Node("op4").Error)
+	assert.Equal(t, StatusSkipped, report.Node("op5").Status)
+	assert.Equal(t, 0, report.Node("op5").Attempts)
+	assert.Nil(t, report.Node("op6"))
+
+	data, err := json.Marshal(report)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"id":"op5","prev":["op4"],"status":"skipped"`)
+	var decoded Report
+	assert.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, StatusTimeout, decoded.Node("op3").Status)
+	assert.Equal(t, report.WallTime, decoded.WallTime)
+}
@@ -0,0 +1,217 @@
+package godag
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// resourceClaim is a resource tag declared by Node.WithResource
+type resourceClaim struct {
+	tag    string
+	weight int
+}
+
+// WithResource declares that the op uses weight units of the resource tag
+// (e.g. the store queried by the op). The nodes of all the runs sharing the
+// same Resources (see DAG.WithResources) only start when every tag of the
+// node has enough units left, the time waiting for them is reported as
+// NodeReport.ResourceWait apart from the execution time. The units are held
+// until the op returns, even if the run abandoned it (see GetAbandonedOps).
+func (n *Node) WithResource(tag string, weight int) *Node {
+	if weight <= 0 {
+		weight = 1
+	}
+	n.resources = append(n.resources, resourceClaim{tag: tag, weight: weight})
+	return n
+}
+
+// Resources is a set of per-tag semaphores shared by concurrent runs. A tag
+// without limit is unbounded, a node claiming more than the limit of a tag
+// takes the whole tag.
+type Resources struct {
+	mu      sync.Mutex
+	limits  map[string]int
+	used    map[string]int
+	waiters []*resourceWaiter // in the order of arrival
+}
+
+// resourceWaiter is a node waiting for its resources, guarded by Resources.mu
+type resourceWaiter struct {
+	claims []resourceClaim
+	grant  func()
+}
+
+// NewResources creates the semaphores, limits maps a tag to its capacity
+func NewResources(limits map[string]int) *Resources {
+	r := &Resources{
+		limits: make(map[string]int, len(limits)),
+		used:   make(map[string]int),
+	}
+	for tag, limit := range limits {
+		r.limits[tag] = limit
+	}
+	return r
+}
+
+// InUse returns the units of tag held by the running nodes
+func (r *Resources) InUse(tag string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.used[tag]
+}
+
+// WithResources makes the run enforce the limits of resources, which can be
+// shared by any number of runs
+func (p *DAG) WithResources(resources *Resources) *DAG {
+	p.resources = resources
+	return p
+}
+
+// weight returns the units of tag taken by a claim of weight
+func (r *Resources) weight(tag string, weight int) int {
+	if limit, ok := r.limits[tag]; ok && weight > limit {
+		return limit
+	}
+	return weight
+}
+
+// fits reports whether every claim can be taken now, guarded by r.mu
+func (r *Resources) fits(claims []resourceClaim) bool {
+	for _, c := range claims {
+		limit, ok := r.limits[c.tag]
+		if ok && r.used[c.tag]+r.weight(c.tag, c.weight) > limit {
+			return false
+		}
+	}
+	return true
+}
+
+// take is guarded by r.mu
+func (r *Resources) take(claims []resourceClaim) {
+	for _, c := range claims {
+		r.used[c.tag] += r.weight(c.tag, c.weight)
+	}
+}
+
+// acquire takes all the claims at once. It returns true if they are taken
+// now, otherwise grant is called once they are taken later, unless the
+// returned waiter is canceled before.
+func (r *Resources) acquire(claims []resourceClaim, grant func()) (bool, *resourceWaiter) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fits(claims) {
+		r.take(claims)
+		return true, nil
+	}
+	w := &resourceWaiter{claims: claims, grant: grant}
+	r.waiters = append(r.waiters, w)
+	return false, w
+}
+
+// cancel removes w from the waiters, false if it is already granted
+func (r *Resources) cancel(w *resourceWaiter) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.waiters {
+		if r.waiters[i] == w {
+			r.waiters = append(r.waiters[:i], r.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// release gives back the claims and grants the waiters which fit now, in the
+// order of arrival. A waiter which does not fit does not block the ones after it.
+func (r *Resources) release(claims []resourceClaim) {
+	r.mu.Lock()
+	for _, c := range claims {
+		r.used[c.tag] -= r.weight(c.tag, c.weight)
+	}
+	var granted []*resourceWaiter
+	waiters := r.waiters[:0]
+	for _, w := range r.waiters {
+		if r.fits(w.claims) {
+			r.take(w.claims)
+			granted = append(granted, w)
+		} else {
+			waiters = append(waiters, w)
+		}
+	}
+	r.waiters = waiters
+	r.mu.Unlock()
+	for _, w := range granted {
+		w.grant()
+	}
+}
+
+// acquireResources takes the resources of node idx and calls start, right
+// now or by the executor once they are released by other nodes. If ctx is
+// done while waiting the node is not started, if the run deadline passes it
+// is skipped by ErrRunDeadline. It returns the nodes made ready by start if
+// called right now.
+func (p *DAG) acquireResources(ctx context.Context, idx int, start func() []int) []int {
+	claims := p.tpl.nodes[idx].resources
+	if p.resources == nil || len(claims) == 0 {
+		return start()
+	}
+	waitStart := time.Now()
+	waitCtx, cancelWait := ctx, context.CancelFunc(func() {})
+	if !p.runDeadline.IsZero() {
+		waitCtx, cancelWait = context.WithDeadline(ctx, p.runDeadline)
+	}
+	var stop func() bool
+	granted := make(chan struct{})
+	ok, w := p.resources.acquire(claims, func() {
+		<-granted // stop is set
+		stop()
+		cancelWait()
+		p.submitNode(idx, func() {
+			p.mu.Lock()
+			p.states[idx].resourceWait = time.Since(waitStart)
+			p.mu.Unlock()
+			p.dispatch(ctx, start())
+		})
+	})
+	if ok {
+		cancelWait()
+		return start()
+	}
+	stop = context.AfterFunc(waitCtx, func() {
+		cancelWait()
+		if !p.resources.cancel(w) {
+			return
+		}
+		p.submit(func() {
+			if ctx.Err() != nil {
+				p.dispatch(ctx, p.finishNode(ctx, idx, StatusNotStarted))
+				return
+			}
+			p.mu.Lock()
+			p.states[idx].err = ErrRunDeadline
+			p.states[idx].resourceWait = time.Since(waitStart)
+			p.overrun = true
+			p.mu.Unlock()
+			p.dispatch(ctx, p.finishNode(ctx, idx, StatusSkipped))
+		})
+	})
+	close(granted)
+	return nil
+}
+
+// releaseResources gives back the resources taken by node idx, or holds them
+// until its abandoned ops returned as they still use the resources
+func (p *DAG) releaseResources(idx int) {
+	claims := p.tpl.nodes[idx].resources
+	if p.resources == nil || len(claims) == 0 {
+		return
+	}
+	p.mu.Lock()
+	held := p.states[idx].running > 0
+	p.states[idx].held = held
+	p.mu.Unlock()
+	if !held {
+		p.resources.release(claims)
+	}
+}
@@ -0,0 +1,124 @@
+package godag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResources(t *testing.T) {
+	var running, max int32
+	start := NewStartNode("start")
+	for i := 0; i < 3; i++ {
+		start.AddNext(fmt.Sprintf("ds%d", i), &CountOp{running: &running, max: &max, sleep: 20 * time.Millisecond}).
+			WithResource("store", 1)
+	}
+	start.AddNext("other", &SimpleOp{data: "other_data", processTime: 10 * time.Millisecond}).WithResource("cache", 1)
+	tpl, err := Compile(start)
+	assert.NoError(t, err)
+
+	resources := NewResources(map[string]int{"store": 2})
+	reports := make([]*Report, 2)
+	var wg sync.WaitGroup
+	for i := range reports {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			dag := tpl.NewDAG(nil).WithResources(resources)
+			assert.NoError(t, dag.Execute(context.Background()))
+			reports[i] = dag.GetReport()
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&max))
+	assert.Equal(t, 0, resources.InUse("store"))
+
+	var waited time.Duration
+	for _, report := range reports {
+		for _, id := range []string{"ds0", "ds1", "ds2"} {
+			n := report.Node(id)
+			assert.Equal(t, StatusSuccess, n.Status)
+			assert.True(t, n.Duration < 40*time.Millisecond) // excludes the wait
+			waited += n.ResourceWait
+		}
+		assert.Equal(t, time.Duration(0), report.Node("other").ResourceWait) // no limit on cache
+	}
+	assert.True(t, waited >= 40*time.Millisecond) // 6 ops of 20ms, 2 at once
+}
+
+func TestResourceWeight(t *testing.T) {
+	var running, max int32
+	start := NewStartNode("start")
+	start.AddNext("heavy", &CountOp{running: &running, max: &max, sleep: 20 * time.Millisecond}).WithResource("store", 5)
+	start.AddNext("light", &CountOp{running: &running, max: &max, sleep: 20 * time.Millisecond}).WithResource("store", 1)
+
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	dag.WithResources(NewResources(map[string]int{"store": 2})) // heavy takes the whole store
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, int32(1), max)
+}
+
+func TestResourceCancel(t *testing.T) {
+	start := NewStartNode("start")
+	start.AddNext("op1", &SimpleOp{data: "op1_data", processTime: 100 * time.Millisecond}).WithResource("store", 1)
+	start.AddNext("op2", &SimpleOp{data: "op2_data", processTime: 100 * time.Millisecond}).WithResource("store", 1)
+
+	resources := NewResources(map[string]int{"store": 1})
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	dag.WithResources(resources)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	err := dag.Execute(ctx)
+	assert.Error(t, err)
+
+	status := dag.GetRunStatus()
+	assert.Equal(t, 1, len(status.Canceled))   // running when canceled
+	assert.Equal(t, 1, len(status.NotStarted)) // waiting for the store
+
+	// the op ignoring ctx still uses the store until it returns
+	assert.Equal(t, 1, resources.InUse("store"))
+	assert.Eventually(t, func() bool {
+		return resources.InUse("store") == 0
+	}, time.Second, 5*time.Millisecond)
+	assert.Empty(t, dag.GetAbandonedOps())
+}
+
+func TestResourceDeadline(t *testing.T) {
+	resources := NewResources(map[string]int{"store": 1})
+	holder := NewStartNode("start")
+	holder.AddNext("hold", &SimpleOp{data: "hold_data", processTime: 200 * time.Millisecond}).WithResource("store", 1)
+	var other DAG
+	assert.NoError(t, other.Init(holder, nil))
+	other.WithResources(resources)
+	done := make(chan error)
+	go func() { done <- other.Execute(context.Background()) }()
+	for resources.InUse("store") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the run does not wait for the store past its deadline
+	start := NewStartNode("start")
+	start.AddNext("op1", &SimpleOp{data: "op1_data"}).WithResource("store", 1)
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	dag.WithResources(resources).WithTimeout(30 * time.Millisecond)
+	startTime := time.Now()
+	err := dag.Execute(context.Background())
+	assert.True(t, time.Since(startTime) < 100*time.Millisecond)
+	assert.True(t, errors.Is(err, ErrRunDeadline))
+	report := dag.GetReport()
+	assert.Equal(t, StatusSkipped, report.Node("op1").Status)
+	assert.Equal(t, ErrRunDeadline.Error(), report.Node("op1").Error)
+	assert.True(t, report.Node("op1").ResourceWait >= 30*time.Millisecond)
+
+	assert.NoError(t, <-done)
+	assert.Equal(t, 0, resources.InUse("store"))
+}
@@ -0,0 +1,48 @@
+package godag
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Attempt is the context key of the attempt number (start from 1) of the running op,
+// get it by ctx.Value(StateKey(Attempt)).(int)
+const Attempt = "__attempt__"
+
+// ErrTimeout is the error of an attempt which exceeded the node timeout
+var ErrTimeout = errors.New("godag: op timeout")
+
+// RetryPolicy describes how a failed op is retried, see Node.WithRetry
+type RetryPolicy struct {
+	MaxAttempts  int                  // attempts including the first one, <= 1 means no retry
+	Backoff      time.Duration        // wait before the second attempt
+	Multiplier   float64              // backoff is multiplied after each attempt, <= 1 means fixed backoff
+	MaxBackoff   time.Duration        // upper bound of backoff, 0 means no bound
+	Jitter       float64              // randomize backoff by +/- Jitter*backoff, between 0 and 1
+	RetryOn      func(err error) bool // whether err is retryable, nil means any error (including ErrTimeout)
+	TotalTimeout time.Duration        // budget of all attempts and backoff, 0 means no budget
+}
+
+func (r *RetryPolicy) shouldRetry(attempt int, err error) bool {
+	if attempt >= r.MaxAttempts {
+		return false
+	}
+	return r.RetryOn == nil || r.RetryOn(err)
+}
+
+// backoff returns the time to wait after the attempt failed
+func (r *RetryPolicy) backoff(attempt int) time.Duration {
+	d := float64(r.Backoff)
+	if r.Multiplier > 1 {
+		d *= math.Pow(r.Multiplier, float64(attempt-1))
+	}
+	if r.MaxBackoff > 0 && d > float64(r.MaxBackoff) {
+		d = float64(r.MaxBackoff)
+	}
+	if r.Jitter > 0 {
+		d += d * r.Jitter * (2*rand.Float64() - 1)
+	}
+	return time.Duration(d)
+}
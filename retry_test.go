@@ -0,0 +1,94 @@
+package godag
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// FlakyOp fails until the attempt reaches okAttempt
+type FlakyOp struct {
+	okAttempt int
+	sleep     time.Duration
+	calls     int32
+}
+
+func (o *FlakyOp) ProcessE(ctx context.Context, global interface{}, input ...interface{}) (interface{}, error) {
+	atomic.AddInt32(&o.calls, 1)
+	attempt := ctx.Value(StateKey(Attempt)).(int)
+	if attempt < o.okAttempt {
+		time.Sleep(o.sleep)
+		return nil, errors.New("flaky")
+	}
+	return attempt, nil
+}
+
+func TestRetry(t *testing.T) {
+	start := NewStartNode("start")
+	op1 := &FlakyOp{okAttempt: 3}
+	start.AddNextE("op1", op1).WithRetry(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, Multiplier: 2, Jitter: 0.5})
+	op2 := &FlakyOp{okAttempt: 3}
+	start.AddNextE("op2", op2).WithRetry(RetryPolicy{MaxAttempts: 2})
+	op3 := &FlakyOp{okAttempt: 3}
+	start.AddNextE("op3", op3).WithRetry(RetryPolicy{
+		MaxAttempts: 5,
+		RetryOn:     func(err error) bool { return err == ErrTimeout },
+	})
+
+	var dag DAG
+	dag.Init(start, nil)
+	err := dag.Execute(context.TODO())
+	assert.Error(t, err)
+	assert.ElementsMatch(t, []string{"op2", "op3"}, err.(*RunError).FailedIDs())
+	assert.Equal(t, 3, dag.GetStateKeeper().GetOutput("op1"))
+	assert.EqualValues(t, 3, op1.calls)
+	assert.EqualValues(t, 2, op2.calls)
+	assert.EqualValues(t, 1, op3.calls) // "flaky" is not retryable
+}
+
+func TestRetryTimeout(t *testing.T) {
+	start := NewStartNode("start")
+	// every attempt times out except the third one
+	op1 := &SleepOp{sleep: map[int]time.Duration{1: time.Second, 2: time.Second}}
+	start.AddNextE("op1", op1).WithTimeout(20 * time.Millisecond).WithRetry(RetryPolicy{MaxAttempts: 3})
+	op2 := &SleepOp{sleep: map[int]time.Duration{1: time.Second, 2: time.Second}}
+	start.AddNextE("op2", op2).WithTimeout(20 * time.Millisecond).
+		WithRetry(RetryPolicy{MaxAttempts: 3, Backoff: 10 * time.Millisecond, TotalTimeout: 50 * time.Millisecond})
+
+	var dag DAG
+	dag.Init(start, nil)
+	startTime := time.Now()
+	assert.NoError(t, dag.Execute(context.TODO()))
+	assert.True(t, time.Since(startTime) < 500*time.Millisecond)
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("op1"))
+	assert.Equal(t, 3, dag.GetStateKeeper().GetOutput("op1"))
+	assert.Equal(t, StatusTimeout, dag.GetNodeStatus("op2"))
+}
+
+// SleepOp sleeps by the attempt number and returns the attempt
+type SleepOp struct {
+	sleep map[int]time.Duration
+}
+
+func (o *SleepOp) ProcessE(ctx context.Context, global interface{}, input ...interface{}) (interface{}, error) {
+	attempt := ctx.Value(StateKey(Attempt)).(int)
+	time.Sleep(o.sleep[attempt])
+	return attempt, nil
+}
+
+func TestRetryBackoff(t *testing.T) {
+	r := RetryPolicy{Backoff: 10 * time.Millisecond, Multiplier: 2, MaxBackoff: 30 * time.Millisecond}
+	assert.Equal(t, 10*time.Millisecond, r.backoff(1))
+	assert.Equal(t, 20*time.Millisecond, r.backoff(2))
+	assert.Equal(t, 30*time.Millisecond, r.backoff(3))
+
+	r = RetryPolicy{Backoff: 10 * time.Millisecond, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		d := r.backoff(1)
+		assert.True(t, d >= 5*time.Millisecond && d <= 15*time.Millisecond)
+	}
+}
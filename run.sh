@@ -1,3 +1,4 @@
 go run examples/simple/simple.go
 go run examples/state/state.go
-go run examples/op_engine/op_engine.go
\ No newline at end of file
+go run examples/op_engine/op_engine.go
+go run examples/typed/typed.go
\ No newline at end of file
@@ -0,0 +1,80 @@
+package godag
+
+import (
+	"sort"
+	"time"
+)
+
+// SchedulePolicy decides which ready node gets a worker first when the
+// executor of the run is a PriorityExecutor (e.g. Pool) and the workers are busy
+type SchedulePolicy int
+
+const (
+	// ScheduleFIFO runs the ready nodes in the order they become ready. This
+	// is the default policy.
+	ScheduleFIFO SchedulePolicy = iota
+	// SchedulePriority runs the ready nodes with higher Node.WithPriority first.
+	SchedulePriority
+	// ScheduleCriticalPath runs first the ready nodes with the longest path
+	// to the end of the graph, where each node is weighted by its estimate
+	// (declared by Node.WithExpectedDuration or observed by the previous runs
+	// of the same Template). A node without estimate counts as 1ns so that
+	// the longest chain of unknown nodes still goes first.
+	ScheduleCriticalPath
+)
+
+// WithPriority sets the priority of the node used by SchedulePriority, higher runs first
+func (n *Node) WithPriority(priority int64) *Node {
+	n.priority = priority
+	return n
+}
+
+// WithSchedulePolicy sets the order in which the ready nodes get a worker
+func (p *DAG) WithSchedulePolicy(policy SchedulePolicy) *DAG {
+	p.schedule = policy
+	return p
+}
+
+// initPriorities computes the priority of each node when the run starts
+func (p *DAG) initPriorities() {
+	p.priorities = nil
+	switch p.schedule {
+	case SchedulePriority:
+		p.priorities = make([]int64, len(p.tpl.nodes))
+		for i, node := range p.tpl.nodes {
+			p.priorities[i] = node.priority
+		}
+	case ScheduleCriticalPath:
+		tails := p.tpl.longestPaths(func(idx int) time.Duration {
+			if estimate := p.tpl.estimate(idx); estimate > 0 {
+				return estimate
+			}
+			return 1
+		})
+		p.priorities = make([]int64, len(tails))
+		for i := range tails {
+			p.priorities[i] = int64(tails[i])
+		}
+	}
+}
+
+// submitNode runs a task of node idx by the executor, by the priority of the
+// node if the run is prioritized
+func (p *DAG) submitNode(idx int, task func()) {
+	if e, ok := p.executor.(PriorityExecutor); ok && p.priorities != nil {
+		e.SubmitPriority(task, p.priorities[idx])
+		return
+	}
+	p.submit(task)
+}
+
+// sortReady orders the nodes made ready together by priority, so that the
+// idle workers take the most important one first
+func (p *DAG) sortReady(ready []int) {
+	if p.priorities == nil || len(ready) < 2 {
+		return
+	}
+	sort.SliceStable(ready, func(i, j int) bool {
+		return p.priorities[ready[i]] > p.priorities[ready[j]]
+	})
+}
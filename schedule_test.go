@@ -0,0 +1,121 @@
+package godag
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolPriority(t *testing.T) {
+	pool := NewPool(1, 8)
+	defer pool.Close()
+	block := make(chan struct{})
+	pool.Submit(func() { <-block })
+	for pool.Stats().Busy == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	order := make(chan string, 5)
+	pool.SubmitPriority(func() { order <- "p1" }, 1)
+	pool.SubmitPriority(func() { order <- "p3" }, 3)
+	pool.SubmitPriority(func() { order <- "p2" }, 2)
+	pool.SubmitPriority(func() { order <- "p3_later" }, 3)
+	pool.Submit(func() { order <- "control" })
+	close(block)
+	for _, want := range []string{"control", "p3", "p3_later", "p2", "p1"} {
+		assert.Equal(t, want, <-order)
+	}
+}
+
+// buildWideComplex builds the graph of TestComplex (durations in unit) with
+// leaves extra selects added before it, which are ready at the same time as ds1
+func buildWideComplex(unit time.Duration, leaves int) *Node {
+	add := func(parent *Node, id string, units int) *Node {
+		d := time.Duration(units) * unit
+		return parent.AddNext(id, &QuietOp{d: d}).WithExpectedDuration(d)
+	}
+	start := NewStartNode("start")
+	for i := 0; i < leaves; i++ {
+		add(start, fmt.Sprintf("leaf%d", i), 1)
+	}
+	ds1 := add(start, "ds1", 1)
+	ds2 := add(start, "ds2", 1)
+	ds3 := add(start, "ds3", 1)
+	add(ds3, "ds_uniq_exp", 1)
+	ds_all_play := add(ds1, "ds_all_play", 2)
+	ds2.AddNextNode(ds_all_play)
+	ds_valid_dur := add(ds_all_play, "ds_valid_dur", 3)
+	ds_valid_dur2 := add(ds_valid_dur, "ds_valid_dur2", 1)
+	fe_session := add(ds_valid_dur2, "fe_session", 2)
+	add(fe_session, "fe_pvreal", 3)
+	add(ds_valid_dur, "fe_pvreal2", 3)
+	return start
+}
+
+// QuietOp sleeps for d without logging
+type QuietOp struct {
+	d time.Duration
+}
+
+func (o *QuietOp) Process(ctx context.Context, global interface{}, input ...interface{}) interface{} {
+	time.Sleep(o.d)
+	return nil
+}
+
+func TestCriticalPathPriorities(t *testing.T) {
+	tpl, err := Compile(buildWideComplex(time.Millisecond, 2))
+	assert.NoError(t, err)
+	dag := tpl.NewDAG(nil).WithSchedulePolicy(ScheduleCriticalPath)
+	dag.initPriorities()
+	priority := func(id string) int64 {
+		return dag.priorities[tpl.index[id]]
+	}
+	assert.Equal(t, int64(12*time.Millisecond), priority("ds1")) // on the critical path
+	assert.Equal(t, int64(2*time.Millisecond), priority("ds3"))
+	assert.Equal(t, int64(time.Millisecond), priority("leaf0"))
+	assert.True(t, priority("ds_valid_dur2") > priority("fe_pvreal2"))
+}
+
+func TestScheduleCriticalPath(t *testing.T) {
+	tpl, err := Compile(buildWideComplex(10*time.Millisecond, 6))
+	assert.NoError(t, err)
+	makespan := func(policy SchedulePolicy) time.Duration {
+		pool := NewPool(2, 64)
+		defer pool.Close()
+		dag := tpl.NewDAG(nil).WithExecutor(pool).WithSchedulePolicy(policy)
+		assert.NoError(t, dag.Execute(context.Background()))
+		return dag.GetReport().WallTime
+	}
+	fifo, critical := makespan(ScheduleFIFO), makespan(ScheduleCriticalPath)
+	// the critical path is 120ms and 240ms of work share 2 workers
+	assert.True(t, critical < fifo, "critical path first %v, fifo %v", critical, fifo)
+	assert.True(t, critical < 140*time.Millisecond, "critical path first %v", critical)
+}
+
+func BenchmarkScheduleWide(b *testing.B) {
+	tpl, err := Compile(buildWideComplex(time.Millisecond, 6))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, policy := range []struct {
+		name   string
+		policy SchedulePolicy
+	}{{"fifo", ScheduleFIFO}, {"critical_path", ScheduleCriticalPath}} {
+		b.Run(policy.name, func(b *testing.B) {
+			pool := NewPool(2, 64)
+			defer pool.Close()
+			var total time.Duration
+			for i := 0; i < b.N; i++ {
+				dag := tpl.NewDAG(nil).WithExecutor(pool).WithSchedulePolicy(policy.policy)
+				if err := dag.Execute(context.Background()); err != nil {
+					b.Fatal(err)
+				}
+				total += dag.GetReport().WallTime
+			}
+			b.ReportMetric(float64(total.Microseconds())/float64(b.N), "makespan-us/op")
+		})
+	}
+}
@@ -18,27 +18,55 @@ type StateKeeper interface {
 	ClearAll()                                          // clear all
 }
 
+// DefaultStateKeeper keeps the outputs in State. GetInput returns, in order of
+// precedence, the input of the edge set by SetEdgeInput, the input of the
+// node set by SetInput, and the output of the parent.
 type DefaultStateKeeper struct {
 	mu     sync.Mutex
 	State  map[string]interface{}
+	Inputs map[string]interface{} // node id or edgeKey => input
 	Global interface{}
 }
 
 func NewDefaultStateKeeper() *DefaultStateKeeper {
 	return &DefaultStateKeeper{
-		State: make(map[string]interface{}),
+		State:  make(map[string]interface{}),
+		Inputs: make(map[string]interface{}),
 	}
 }
 
+// SetInput sets the input of "curID" from every parent
 func (sk *DefaultStateKeeper) SetInput(curID string, input interface{}) {
 	sk.mu.Lock()
 	defer sk.mu.Unlock()
-	sk.State[curID] = input
+	sk.setInput(curID, input)
 }
 
+// SetEdgeInput sets the input of "curID" generated by "parentID"
+func (sk *DefaultStateKeeper) SetEdgeInput(parentID string, curID string, input interface{}) {
+	sk.mu.Lock()
+	defer sk.mu.Unlock()
+	sk.setInput(edgeKey(parentID, curID), input)
+}
+
+// setInput creates Inputs if the keeper is built as a literal without it
+func (sk *DefaultStateKeeper) setInput(key string, input interface{}) {
+	if sk.Inputs == nil {
+		sk.Inputs = make(map[string]interface{})
+	}
+	sk.Inputs[key] = input
+}
+
+// GetInput reads a nil Inputs as empty
 func (sk *DefaultStateKeeper) GetInput(parentID string, curID string) interface{} {
 	sk.mu.Lock()
 	defer sk.mu.Unlock()
+	if input, ok := sk.Inputs[edgeKey(parentID, curID)]; ok {
+		return input
+	}
+	if input, ok := sk.Inputs[curID]; ok {
+		return input
+	}
 	return sk.State[parentID]
 }
 
@@ -74,4 +102,10 @@ func (sk *DefaultStateKeeper) ClearAll() {
 	sk.mu.Lock()
 	defer sk.mu.Unlock()
 	sk.State = make(map[string]interface{})
-}
\ No newline at end of file
+	sk.Inputs = make(map[string]interface{})
+}
+
+// edgeKey is the key of the input of the edge parentID -> curID
+func edgeKey(parentID string, curID string) string {
+	return parentID + "->" + curID
+}
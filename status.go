@@ -0,0 +1,60 @@
+package godag
+
+import "fmt"
+
+// NodeStatus is the outcome of a node in one run of the DAG
+type NodeStatus int
+
+const (
+	StatusNotStarted NodeStatus = iota // never scheduled
+	StatusSuccess                      // op returned normally
+	StatusTimeout                      // op exceeded the node timeout
+	StatusFailed                       // op returned an error
+	StatusSkipped                      // not run because of the failure policy
+	StatusCanceled                     // op was running when the run was canceled
+	StatusPanic                        // op panicked
+)
+
+var statusNames = [...]string{
+	StatusNotStarted: "not_started",
+	StatusSuccess:    "success",
+	StatusTimeout:    "timeout",
+	StatusFailed:     "failed",
+	StatusSkipped:    "skipped",
+	StatusCanceled:   "canceled",
+	StatusPanic:      "panic",
+}
+
+func (s NodeStatus) String() string {
+	if s < 0 || int(s) >= len(statusNames) {
+		return "unknown"
+	}
+	return statusNames[s]
+}
+
+// failed reports whether the op failed, by error or by panic
+func (s NodeStatus) failed() bool {
+	return s == StatusFailed || s == StatusPanic
+}
+
+func (s NodeStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *NodeStatus) UnmarshalText(text []byte) error {
+	for i, name := range statusNames {
+		if name == string(text) {
+			*s = NodeStatus(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("godag: unknown node status %q", text)
+}
+
+// RunStatus groups the nodes of a run by how far they got
+type RunStatus struct {
+	Completed  []string // the op ran to the end, whatever the outcome is
+	Canceled   []string // the op was running when the run was canceled
+	Skipped    []string // not run because of the failure policy
+	NotStarted []string // never started
+}
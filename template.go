@@ -0,0 +1,143 @@
+package godag
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Template is the compiled, immutable form of a graph built by Node.AddNext etc.
+// The graph is built once and frozen by Compile, then any number of runs can be
+// created from it by NewDAG and executed concurrently, each run has its own
+// counters, state keeper and timings.
+//
+// NOTE: ops are shared by all the runs, so they should be safe for concurrent use.
+type Template struct {
+	nodes          []*Node        // frozen copy of the nodes, nodes[0] is the start node
+	index          map[string]int // node id => index of nodes
+	prev           [][]int        // index of parents, in the order of args passed to op
+	prevPorts      [][]string     // output port of each parent in prev read as the positional input, nil if none
+	inputs         [][]tplInput   // named inputs of each node
+	prevTransforms [][]*Transform // transform of the edge from each parent in prev, nil if none
+	racing         []bool         // the op can be canceled by a sibling, see WithFirstSuccess
+	mapPrev        []int          // index in prev of the parent giving the elements of each map node
+	next           [][]int        // index of children
+	indegree       []int          // initial indegree of each node
+	order          []int          // index of nodes in topological order
+	history        []int64        // moving average of the durations observed by runs, accessed atomically
+}
+
+// Compile validates the graph linked to startNode and freezes it into a Template.
+// Modifying the nodes after Compile will not affect the Template.
+func Compile(startNode *Node) (*Template, error) {
+	if err := Validate(startNode); err != nil {
+		return nil, err
+	}
+	t := &Template{
+		index: make(map[string]int),
+	}
+	// collect the nodes in BFS order
+	origins := []*Node{startNode}
+	seen := map[*Node]bool{startNode: true}
+	for i := 0; i < len(origins); i++ {
+		for _, child := range origins[i].next {
+			if !seen[child] {
+				seen[child] = true
+				origins = append(origins, child)
+			}
+		}
+	}
+	for i, origin := range origins {
+		t.index[origin.id] = i
+		node := *origin
+		node.prev, node.next, node.inputs, node.transforms, node.mapFrom = nil, nil, nil, nil, nil
+		t.nodes = append(t.nodes, &node)
+	}
+	t.prev = make([][]int, len(origins))
+	t.next = make([][]int, len(origins))
+	t.prevPorts = make([][]string, len(origins))
+	t.inputs = make([][]tplInput, len(origins))
+	t.prevTransforms = make([][]*Transform, len(origins))
+	t.mapPrev = make([]int, len(origins))
+	t.indegree = make([]int, len(origins))
+	for i, origin := range origins {
+		for _, parent := range origin.prev {
+			t.prev[i] = append(t.prev[i], t.index[parent.id])
+		}
+		for _, child := range origin.next {
+			t.next[i] = append(t.next[i], t.index[child.id])
+		}
+		t.compileInputs(i, origin)
+		if len(origin.transforms) > 0 {
+			t.prevTransforms[i] = make([]*Transform, len(origin.prev))
+			for j, parent := range origin.prev {
+				t.prevTransforms[i][j] = origin.transforms[parent]
+			}
+		}
+		for j, parent := range origin.prev {
+			if parent == origin.mapFrom {
+				t.mapPrev[i] = j
+			}
+		}
+		t.indegree[i] = origin.indegree
+	}
+	indegree := append([]int(nil), t.indegree...)
+	t.order = append(t.order, 0)
+	for i := 0; i < len(t.order); i++ {
+		for _, child := range t.next[t.order[i]] {
+			indegree[child]--
+			if indegree[child] == 0 {
+				t.order = append(t.order, child)
+			}
+		}
+	}
+	t.compileTriggers()
+	t.history = make([]int64, len(origins))
+	return t, nil
+}
+
+// NewDAG creates a run of the template, stateKeeper can be nil to use DefaultStateKeeper
+func (t *Template) NewDAG(stateKeeper StateKeeper) *DAG {
+	dag := &DAG{}
+	dag.init(t, stateKeeper)
+	return dag
+}
+
+// estimate returns the expected duration of node idx, which is declared by
+// Node.WithExpectedDuration or observed by the previous runs, 0 if unknown
+func (t *Template) estimate(idx int) time.Duration {
+	if expected := t.nodes[idx].expected; expected > 0 {
+		return expected
+	}
+	return time.Duration(atomic.LoadInt64(&t.history[idx]))
+}
+
+// longestPaths returns the length of the longest path starting from each
+// node (including the node itself), each node weighted by weight
+func (t *Template) longestPaths(weight func(idx int) time.Duration) []time.Duration {
+	tails := make([]time.Duration, len(t.nodes))
+	for k := len(t.order) - 1; k >= 0; k-- {
+		i := t.order[k]
+		var longest time.Duration
+		for _, child := range t.next[i] {
+			if tails[child] > longest {
+				longest = tails[child]
+			}
+		}
+		tails[i] = weight(i) + longest
+	}
+	return tails
+}
+
+// observe updates the moving average of the duration of node idx
+func (t *Template) observe(idx int, d time.Duration) {
+	for {
+		old := atomic.LoadInt64(&t.history[idx])
+		avg := int64(d)
+		if old > 0 {
+			avg = old + (int64(d)-old)/5
+		}
+		if atomic.CompareAndSwapInt64(&t.history[idx], old, avg) {
+			return
+		}
+	}
+}
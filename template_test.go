@@ -0,0 +1,71 @@
+package godag
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// ConcatOp joins the global and the inputs
+type ConcatOp struct {
+	name string
+}
+
+func (o *ConcatOp) Process(ctx context.Context, global interface{}, input ...interface{}) interface{} {
+	return fmt.Sprint(global, o.name, input)
+}
+
+func TestTemplate(t *testing.T) {
+	/**
+	           |-> op1 -> op3 -> |
+	    start->|                 |-> op4
+	           |-> op2 --------->|
+	**/
+	start := NewStartNode("start")
+	op1 := start.AddNext("op1", &ConcatOp{name: "op1"})
+	op2 := start.AddNext("op2", &ConcatOp{name: "op2"})
+	op3 := op1.AddNext("op3", &ConcatOp{name: "op3"})
+	op4 := op3.AddNext("op4", &ConcatOp{name: "op4"})
+	op2.AddNextNode(op4)
+
+	tpl, err := Compile(start)
+	assert.NoError(t, err)
+	// modifying the builder does not affect the template
+	op4.AddNext("op5", &ConcatOp{name: "op5"})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sk := NewDefaultStateKeeper()
+			sk.SetGlobal(i)
+			dag := tpl.NewDAG(sk)
+			assert.NoError(t, dag.Execute(context.Background()))
+			outputs := sk.GetAllOutput()
+			assert.Equal(t, 4, len(outputs))
+			op3 := fmt.Sprint(i, "op3", []interface{}{fmt.Sprint(i, "op1", []interface{}{nil})})
+			op2 := fmt.Sprint(i, "op2", []interface{}{nil})
+			assert.Equal(t, fmt.Sprint(i, "op4", []interface{}{op3, op2}), outputs["op4"])
+			assert.Equal(t, StatusSuccess, dag.GetNodeStatus("op4"))
+		}(i)
+	}
+	wg.Wait()
+
+	// the builder nodes are not consumed by runs
+	assert.Equal(t, 2, op4.indegree)
+}
+
+func TestCompileDuplicateID(t *testing.T) {
+	start := NewStartNode("start")
+	start.AddNext("op1", &ConcatOp{})
+	start.AddNext("op1", &ConcatOp{})
+	_, err := Compile(start)
+	assert.Error(t, err)
+
+	var dag DAG
+	assert.Error(t, dag.Init(start, nil))
+}
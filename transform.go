@@ -0,0 +1,112 @@
+package godag
+
+import (
+	"fmt"
+	"runtime/debug"
+	"sync"
+)
+
+// Transform converts the output of a parent into the input of a child, e.g.
+// a projection, a filter or a type conversion, see Node.WithTransform
+type Transform struct {
+	name string
+	fn   func(output interface{}) (interface{}, error)
+}
+
+// NewTransform creates a transform, name is used in messages. The same
+// *Transform on the edges from one parent to several children runs once
+// per run and the children share its result.
+func NewTransform(name string, fn func(output interface{}) (interface{}, error)) *Transform {
+	return &Transform{name: name, fn: fn}
+}
+
+// Name returns the name of the transform
+func (t *Transform) Name() string {
+	return t.name
+}
+
+// EdgeStateKeeper is a StateKeeper which keeps the input of each edge, the
+// transformed outputs are saved by SetEdgeInput and read back by GetInput.
+// DefaultStateKeeper implements it.
+type EdgeStateKeeper interface {
+	StateKeeper
+	SetEdgeInput(parentID string, curID string, input interface{}) // set the input of "curID" generated by "parentID"
+}
+
+// WithTransform links parent to n if not linked yet and transforms every
+// input of n fed by parent (the positional one and the named ones of any
+// port) by t. A failed transform fails n as if its op returned the error.
+func (n *Node) WithTransform(parent *Node, t *Transform) *Node {
+	parent.AddNextNode(n)
+	if n.transforms == nil {
+		n.transforms = make(map[*Node]*Transform)
+	}
+	n.transforms[parent] = t
+	return n
+}
+
+// transformKey identifies a transformed output in a run
+type transformKey struct {
+	parentID  string // qualified by the port if any
+	transform *Transform
+}
+
+type transformResult struct {
+	once   sync.Once
+	output interface{}
+	err    error
+}
+
+// readInput reads the input of node idx fed by the output port "port" of
+// its parent prev[i], transformed by the edge if needed. The input from a
+// parent not finished is nil, it is not transformed.
+func (p *DAG) readInput(idx int, i int, port string) (interface{}, error) {
+	id := p.tpl.nodes[idx].id
+	parentID := portID(p.tpl.nodes[p.tpl.prev[idx][i]].id, port)
+	var t *Transform
+	if transforms := p.tpl.prevTransforms[idx]; transforms != nil {
+		t = transforms[i]
+	}
+	if t == nil {
+		return p.stateKeeper.GetInput(parentID, id), nil
+	}
+
+	key := transformKey{parentID: parentID, transform: t}
+	p.mu.Lock()
+	if !p.states[p.tpl.prev[idx][i]].finished {
+		// a first success node does not wait for the parent, which has no output yet
+		p.mu.Unlock()
+		return nil, nil
+	}
+	if p.transformed == nil {
+		p.transformed = make(map[transformKey]*transformResult)
+	}
+	result, ok := p.transformed[key]
+	if !ok {
+		result = &transformResult{}
+		p.transformed[key] = result
+	}
+	p.mu.Unlock()
+	result.once.Do(func() {
+		result.output, result.err = callTransform(t, p.stateKeeper.GetOutput(parentID))
+	})
+	if result.err != nil {
+		return nil, fmt.Errorf("godag: transform %q of %q: %w", t.name, parentID, result.err)
+	}
+	sk, ok := p.stateKeeper.(EdgeStateKeeper)
+	if !ok {
+		return result.output, nil
+	}
+	sk.SetEdgeInput(parentID, id, result.output)
+	return sk.GetInput(parentID, id), nil
+}
+
+// callTransform runs t, a panic is recovered and returned as *PanicError
+func callTransform(t *Transform, output interface{}) (input interface{}, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			input, err = nil, &PanicError{Value: v, Stack: debug.Stack()}
+		}
+	}()
+	return t.fn(output)
+}
@@ -0,0 +1,129 @@
+package godag
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// plainStateKeeper hides SetEdgeInput of the wrapped state keeper
+type plainStateKeeper struct {
+	StateKeeper
+}
+
+func TestTransform(t *testing.T) {
+	/**
+	                   |-(split)-> op2
+	    start -> op1 ->|-(split)-> op3
+	                   |-(upper)-> op4
+	                   |---------> op5
+	**/
+	var splits int32
+	split := NewTransform("split", func(output interface{}) (interface{}, error) {
+		atomic.AddInt32(&splits, 1)
+		return strings.Split(output.(string), ","), nil
+	})
+	upper := NewTransform("upper", func(output interface{}) (interface{}, error) {
+		return strings.ToUpper(output.(string)), nil
+	})
+	start := NewStartNode("start")
+	op1 := start.AddNext("op1", &SimpleOp{data: "a,b"})
+	op1.AddNext("op2", &ConcatOp{name: "op2"}).WithTransform(op1, split)
+	op1.AddNext("op3", &ConcatOp{name: "op3"}).WithTransform(op1, split)
+	op1.AddNext("op4", &ConcatOp{name: "op4"}).WithTransform(op1, upper)
+	op1.AddNext("op5", &ConcatOp{name: "op5"})
+
+	for _, sk := range []StateKeeper{
+		NewDefaultStateKeeper(),
+		plainStateKeeper{NewDefaultStateKeeper()},
+		&DefaultStateKeeper{State: make(map[string]interface{})}, // built as a literal, Inputs is nil
+	} {
+		atomic.StoreInt32(&splits, 0)
+		var dag DAG
+		assert.NoError(t, dag.Init(start, sk))
+		assert.NoError(t, dag.Execute(context.Background()))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&splits)) // shared by op2 and op3
+		assert.Equal(t, "<nil>op2[[a b]]", sk.GetOutput("op2"))
+		assert.Equal(t, "<nil>op3[[a b]]", sk.GetOutput("op3"))
+		assert.Equal(t, "<nil>op4[A,B]", sk.GetOutput("op4"))
+		assert.Equal(t, "<nil>op5[a,b]", sk.GetOutput("op5"))
+		assert.Equal(t, "a,b", sk.GetOutput("op1"))
+	}
+
+	sk := NewDefaultStateKeeper()
+	var dag DAG
+	assert.NoError(t, dag.Init(start, sk))
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, []string{"a", "b"}, sk.GetInput("op1", "op2"))
+	assert.Equal(t, "A,B", sk.GetInput("op1", "op4"))
+	assert.Equal(t, "a,b", sk.GetInput("op1", "op5"))
+}
+
+func TestTransformError(t *testing.T) {
+	fail := NewTransform("fail", func(output interface{}) (interface{}, error) {
+		return nil, errors.New("bad input")
+	})
+	start := NewStartNode("start")
+	op1 := start.AddNext("op1", &SimpleOp{data: "op1_data"})
+	op1.AddNext("op2", &ConcatOp{name: "op2"}).WithTransform(op1, fail).
+		AddNext("op3", &ConcatOp{name: "op3"})
+
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	assert.Error(t, dag.Execute(context.Background()))
+	assert.Equal(t, StatusFailed, dag.GetNodeStatus("op2"))
+	assert.Equal(t, `godag: transform "fail" of "op1": bad input`, dag.GetReport().Node("op2").Error)
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("op3"))
+}
+
+func TestTransformFirstSuccess(t *testing.T) {
+	/**
+	           |-> fast(10ms) ---------->|-> first
+	    start->|-> slow(30ms) -(upper)-->|
+	                          |-(upper)-> after_slow
+	**/
+	var uppers int32
+	upper := NewTransform("upper", func(output interface{}) (interface{}, error) {
+		atomic.AddInt32(&uppers, 1)
+		return strings.ToUpper(output.(string)), nil
+	})
+	start := NewStartNode("start")
+	first := start.AddNext("fast", &SimpleOp{data: "fast_data", processTime: 10 * time.Millisecond}).
+		AddNext("first", &ConcatOp{name: "first"}).WithFirstSuccess(false)
+	slow := start.AddNext("slow", &SimpleOp{data: "slow_data", processTime: 30 * time.Millisecond})
+	first.WithTransform(slow, upper)
+	slow.AddNext("after_slow", &ConcatOp{name: "after_slow"}).WithTransform(slow, upper)
+
+	// first does not transform the output of slow which is not finished
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, "<nil>first[fast_data <nil>]", dag.GetStateKeeper().GetOutput("first"))
+	assert.Equal(t, "<nil>after_slow[SLOW_DATA]", dag.GetStateKeeper().GetOutput("after_slow"))
+	assert.Equal(t, int32(1), uppers)
+}
+
+func TestStateKeeperInput(t *testing.T) {
+	literal := &DefaultStateKeeper{State: map[string]interface{}{"op1": "output"}}
+	assert.Equal(t, "output", literal.GetInput("op1", "op2"))
+	literal.SetEdgeInput("op1", "op2", "edge input")
+	assert.Equal(t, "edge input", literal.GetInput("op1", "op2"))
+	literal.ClearAll()
+	literal.SetInput("op2", "node input")
+	assert.Equal(t, "node input", literal.GetInput("op1", "op2"))
+
+	sk := NewDefaultStateKeeper()
+	sk.SetOutput("op1", "output")
+	assert.Equal(t, "output", sk.GetInput("op1", "op2"))
+	sk.SetInput("op2", "node input")
+	assert.Equal(t, "node input", sk.GetInput("op1", "op2"))
+	sk.SetEdgeInput("op1", "op2", "edge input")
+	assert.Equal(t, "edge input", sk.GetInput("op1", "op2"))
+	assert.Equal(t, "output", sk.GetInput("op1", "op3"))
+	assert.Equal(t, "output", sk.GetOutput("op1"))
+}
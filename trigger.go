@@ -0,0 +1,161 @@
+package godag
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrTriggerNotMet is the error of a node skipped because its trigger rule is not met
+var ErrTriggerNotMet = errors.New("godag: trigger rule not met")
+
+// ErrRaceLost is the error of a node canceled because a sibling succeeded
+// first, see Node.WithFirstSuccess
+var ErrRaceLost = errors.New("godag: canceled, a sibling succeeded first")
+
+// TriggerRule decides whether a node runs by the outcome of its parents, the
+// parents on a branch not taken (see WithCondition) are not counted. A node
+// whose rule is not met is StatusSkipped with ErrTriggerNotMet.
+type TriggerRule int
+
+const (
+	// TriggerDefault follows the FailurePolicy of the run
+	TriggerDefault TriggerRule = iota
+	// TriggerAllSuccess runs the node if every parent succeeded
+	TriggerAllSuccess
+	// TriggerAnySuccess runs the node if at least one parent succeeded
+	TriggerAnySuccess
+	// TriggerAllDone runs the node whatever the outcome of its parents,
+	// failed parents give nil as the input
+	TriggerAllDone
+	// TriggerQuorum runs the node if at least k parents succeeded, see WithQuorum
+	TriggerQuorum
+	// TriggerFirstSuccess runs the node as soon as the first parent
+	// succeeded, see WithFirstSuccess
+	TriggerFirstSuccess
+)
+
+// WithTrigger sets the trigger rule of n, a parent using a fallback is a
+// success. Under FailFast no node runs once the run is aborted whatever its rule.
+func (n *Node) WithTrigger(rule TriggerRule) *Node {
+	n.trigger = rule
+	return n
+}
+
+// WithQuorum runs n if at least k parents succeeded, once every parent is
+// done. k should be between 1 and the number of parents, see Validate.
+func (n *Node) WithQuorum(k int) *Node {
+	n.trigger = TriggerQuorum
+	n.quorum = k
+	return n
+}
+
+// WithFirstSuccess runs n as soon as the first parent succeeded without
+// waiting for the others, whose inputs are nil unless they finished by then,
+// e.g. to race replicas of the same lookup. If cancelSlower is true the
+// parents not finished are canceled at that time, they are StatusCanceled
+// with ErrRaceLost and their other children are skipped. If no parent
+// succeeded, n is skipped once every parent is done.
+func (n *Node) WithFirstSuccess(cancelSlower bool) *Node {
+	n.trigger = TriggerFirstSuccess
+	n.cancelSlower = cancelSlower
+	return n
+}
+
+// compileTriggers marks the parents of the nodes canceling the slower parents
+func (t *Template) compileTriggers() {
+	t.racing = make([]bool, len(t.nodes))
+	for i, node := range t.nodes {
+		if node.trigger == TriggerFirstSuccess && node.cancelSlower {
+			for _, parent := range t.prev[i] {
+				t.racing[parent] = true
+			}
+		}
+	}
+}
+
+// triggerMet reports whether the trigger rule of node idx is met, an unmet
+// rule is recorded as the error of the node. Guarded by p.mu.
+func (p *DAG) triggerMet(idx int) bool {
+	node := p.tpl.nodes[idx]
+	var total, success int
+	for _, parent := range p.tpl.prev[idx] {
+		state := &p.states[parent]
+		if state.pruned || (state.switched && !containsInt(state.selected, idx)) {
+			continue
+		}
+		total++
+		if state.status == StatusSuccess || state.fallback {
+			success++
+		}
+	}
+	met := true
+	switch node.trigger {
+	case TriggerAllSuccess:
+		met = success == total
+	case TriggerAnySuccess, TriggerFirstSuccess:
+		met = success > 0
+	case TriggerQuorum:
+		met = success >= node.quorum
+	}
+	if !met {
+		p.states[idx].err = ErrTriggerNotMet
+	}
+	return met
+}
+
+// fire reports whether node idx is made ready by its parent which finished,
+// it is called once by each parent
+func (p *DAG) fire(idx int, parent int, succeeded bool) bool {
+	last := atomic.AddInt32(&p.states[idx].indegree, -1) == 0
+	node := p.tpl.nodes[idx]
+	if node.trigger != TriggerFirstSuccess {
+		return last
+	}
+	if succeeded { // a parent not leading to idx does not count, see triggerMet
+		p.mu.Lock()
+		state := &p.states[parent]
+		succeeded = !state.pruned && (!state.switched || containsInt(state.selected, idx))
+		p.mu.Unlock()
+	}
+	if !last && !succeeded {
+		return false
+	}
+	if !atomic.CompareAndSwapInt32(&p.states[idx].fired, 0, 1) {
+		return false
+	}
+	if !last && node.cancelSlower {
+		p.cancelSlower(idx, parent)
+	}
+	return true
+}
+
+// cancelSlower cancels the parents of node idx whose op has not returned but the winner
+func (p *DAG) cancelSlower(idx int, winner int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, parent := range p.tpl.prev[idx] {
+		state := &p.states[parent]
+		if parent == winner || state.returned || state.status != StatusNotStarted {
+			continue
+		}
+		state.lost = true
+		if state.cancel != nil {
+			state.cancel()
+		}
+	}
+}
+
+// raceContext lets the op of node idx be canceled by cancelSlower, false
+// if the node lost the race before it started
+func (p *DAG) raceContext(ctx context.Context, idx int) (context.Context, context.CancelFunc, bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.states[idx].lost {
+		cancel()
+		return nil, nil, false
+	}
+	p.states[idx].cancel = cancel
+	return ctx, cancel, true
+}
@@ -0,0 +1,176 @@
+package godag
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTriggerRules(t *testing.T) {
+	/**
+	           |-> ok1 ->|-> all(all success)
+	    start->|-> bad ->|-> any(any success)
+	           |-> ok2 ->|-> done(all done)
+	                     |-> q2(quorum 2), q3(quorum 3)
+	**/
+	start := NewStartNode("start")
+	parents := []*Node{
+		start.AddNext("ok1", &SimpleOp{data: "ok1_data"}),
+		start.AddNextE("bad", &ErrOp{err: errors.New("bad")}),
+		start.AddNext("ok2", &SimpleOp{data: "ok2_data"}),
+	}
+	children := []*Node{
+		NewNode("all", &ConcatOp{name: "all"}).WithTrigger(TriggerAllSuccess),
+		NewNode("any", &ConcatOp{name: "any"}).WithTrigger(TriggerAnySuccess),
+		NewNode("done", &ConcatOp{name: "done"}).WithTrigger(TriggerAllDone),
+		NewNode("q2", &ConcatOp{name: "q2"}).WithQuorum(2),
+		NewNode("q3", &ConcatOp{name: "q3"}).WithQuorum(3),
+		NewNode("default", &ConcatOp{name: "default"}),
+	}
+	for _, child := range children {
+		for _, parent := range parents {
+			parent.AddNextNode(child)
+		}
+	}
+
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	assert.Error(t, dag.Execute(context.Background()))
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("all"))
+	assert.Equal(t, ErrTriggerNotMet.Error(), dag.GetReport().Node("all").Error)
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("any"))
+	assert.Equal(t, "<nil>any[ok1_data <nil> ok2_data]", dag.GetStateKeeper().GetOutput("any"))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("done"))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("q2"))
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("q3"))
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("default")) // SkipDescendants
+}
+
+func TestQuorumValidate(t *testing.T) {
+	start := NewStartNode("start")
+	op1 := start.AddNext("op1", &SimpleOp{data: "op1_data"})
+	op2 := start.AddNext("op2", &SimpleOp{data: "op2_data"})
+	q0 := op1.AddNext("q0", &ConcatOp{name: "q0"}).WithQuorum(0)
+	q3 := op1.AddNext("q3", &ConcatOp{name: "q3"}).WithQuorum(3)
+	q2 := op1.AddNext("q2", &ConcatOp{name: "q2"}).WithQuorum(2)
+	for _, n := range []*Node{q0, q3, q2} {
+		op2.AddNextNode(n)
+	}
+
+	err := Validate(start)
+	assert.Error(t, err)
+	assert.Equal(t, []string{
+		`node "q0" has quorum 0 but 2 parent(s)`,
+		`node "q3" has quorum 3 but 2 parent(s)`,
+	}, err.(*ValidationError).Inconsistent)
+}
+
+func TestFirstSuccess(t *testing.T) {
+	/**
+	           |-> fast(10ms) ->|
+	    start->|                |-> first
+	           |-> slow(ctx) -->|
+	           |-> bad -------->|
+	**/
+	build := func(cancelSlower bool) *Node {
+		start := NewStartNode("start")
+		first := start.AddNext("fast", &SimpleOp{data: "fast_data", processTime: 10 * time.Millisecond}).
+			AddNext("first", &ConcatOp{name: "first"}).WithFirstSuccess(cancelSlower)
+		slow := start.AddNextE("slow", &CtxOp{}).WithTimeout(200 * time.Millisecond)
+		slow.AddNextNode(first)
+		slow.AddNext("after_slow", &ConcatOp{name: "after_slow"})
+		start.AddNextE("bad", &ErrOp{err: errors.New("bad")}).AddNextNode(first)
+		return start
+	}
+
+	var dag DAG
+	assert.NoError(t, dag.Init(build(true), nil))
+	startTime := time.Now()
+	assert.Error(t, dag.Execute(context.Background())) // bad failed
+	assert.True(t, time.Since(startTime) < 150*time.Millisecond)
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("first"))
+	assert.Equal(t, "<nil>first[fast_data <nil> <nil>]", dag.GetStateKeeper().GetOutput("first"))
+	assert.Equal(t, StatusCanceled, dag.GetNodeStatus("slow"))
+	assert.Equal(t, ErrRaceLost.Error(), dag.GetReport().Node("slow").Error)
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("after_slow"))
+	assert.Len(t, dag.errs, 1) // losing the race is not a failure
+
+	assert.NoError(t, dag.Init(build(false), nil))
+	assert.Error(t, dag.Execute(context.Background()))
+	report := dag.GetReport()
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("first"))
+	assert.Equal(t, StatusTimeout, dag.GetNodeStatus("slow"))
+	assert.True(t, report.Node("first").EndTime.Before(report.Node("slow").EndTime))
+
+	// no parent succeeded
+	start := NewStartNode("start")
+	first := start.AddNextE("bad1", &ErrOp{err: errors.New("bad")}).
+		AddNext("first", &ConcatOp{name: "first"}).WithFirstSuccess(true)
+	start.AddNextE("bad2", &ErrOp{err: errors.New("bad")}).AddNextNode(first)
+	assert.NoError(t, dag.Init(start, nil))
+	assert.Error(t, dag.Execute(context.Background()))
+	assert.Equal(t, StatusSkipped, dag.GetNodeStatus("first"))
+	assert.Equal(t, ErrTriggerNotMet.Error(), dag.GetReport().Node("first").Error)
+}
+
+func TestFirstSuccessSwitch(t *testing.T) {
+	/**
+	           |-> sw(switch) ----->|-> first
+	    start->|         |-> other  |
+	           |-> slow(50ms) ----->|
+	**/
+	start := NewStartNode("start")
+	sw := start.AddNext("sw", &ValueOp{value: "other"}).WithSwitch()
+	first := sw.AddNext("first", &ConcatOp{name: "first"}).WithFirstSuccess(false)
+	sw.AddNext("other", &SimpleOp{data: "other_data"})
+	start.AddNext("slow", &SimpleOp{data: "slow_data", processTime: 50 * time.Millisecond}).AddNextNode(first)
+
+	// sw did not select first, which waits for slow
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("first"))
+	assert.Equal(t, "<nil>first[other slow_data]", dag.GetStateKeeper().GetOutput("first"))
+}
+
+// slowSaveKeeper delays saving the output of a node, so that its op has
+// returned while the node is not finished yet
+type slowSaveKeeper struct {
+	*DefaultStateKeeper
+	id    string
+	delay time.Duration
+}
+
+func (sk *slowSaveKeeper) SetOutput(curID string, output interface{}) {
+	if curID == sk.id {
+		time.Sleep(sk.delay)
+	}
+	sk.DefaultStateKeeper.SetOutput(curID, output)
+}
+
+func TestFirstSuccessReturned(t *testing.T) {
+	/**
+	           |-> fast(10ms) -->|-> first
+	    start->|-> saved(30ms) ->|
+	                             |-> after_saved
+	**/
+	start := NewStartNode("start")
+	first := start.AddNext("fast", &SimpleOp{data: "fast_data", processTime: 10 * time.Millisecond}).
+		AddNext("first", &ConcatOp{name: "first"}).WithFirstSuccess(true)
+	saved := start.AddNext("saved", &SimpleOp{data: "saved_data"})
+	saved.AddNextNode(first)
+	saved.AddNext("after_saved", &ConcatOp{name: "after_saved"})
+
+	// the op of saved returned before fast won, it is not canceled
+	var dag DAG
+	sk := &slowSaveKeeper{DefaultStateKeeper: NewDefaultStateKeeper(), id: "saved", delay: 30 * time.Millisecond}
+	assert.NoError(t, dag.Init(start, sk))
+	assert.NoError(t, dag.Execute(context.Background()))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("first"))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("saved"))
+	assert.Equal(t, StatusSuccess, dag.GetNodeStatus("after_saved"))
+	assert.Equal(t, "<nil>after_saved[saved_data]", sk.GetOutput("after_saved"))
+}
@@ -0,0 +1,190 @@
+package godag
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Global is the context key of the global state passed to typed ops, get it
+// by ctx.Value(StateKey(Global))
+const Global = "__global__"
+
+// TypedNode is a node whose output is of type T. It is built by AddTyped0..3,
+// which only accept parents of the types taken by the func, so a mismatch is
+// a compile error. TypedNode embeds *Node, typed and untyped nodes can be
+// linked by AddNext/AddNextNode as usual, Validate then checks that the
+// output of a typed parent fits the input of a typed child.
+type TypedNode[T any] struct {
+	*Node
+}
+
+// Typed declares that the output of the untyped node n is of type T, so that
+// it can be the parent of typed nodes. The type is checked when the child
+// runs, a mismatch fails the child with an error rather than a panic.
+func Typed[T any](n *Node) *TypedNode[T] {
+	return &TypedNode[T]{Node: n}
+}
+
+// Output returns the output of the node saved in sk, false if there is no
+// output of type T
+func (n *TypedNode[T]) Output(sk StateKeeper) (T, bool) {
+	v, ok := sk.GetOutput(n.id).(T)
+	return v, ok
+}
+
+// AddTyped0 adds a typed node running f after parent, the output of parent is ignored
+func AddTyped0[R any](parent *Node, id string, f func(ctx context.Context) (R, error)) *TypedNode[R] {
+	op := newTypedOp[R](func(ctx context.Context, input []interface{}) (R, error) {
+		return f(ctx)
+	})
+	return &TypedNode[R]{Node: parent.AddNextE(id, op)}
+}
+
+// AddTyped1 adds a typed node running f with the output of a
+func AddTyped1[A, R any](a *TypedNode[A], id string, f func(ctx context.Context, a A) (R, error)) *TypedNode[R] {
+	op := newTypedOp[R](func(ctx context.Context, input []interface{}) (R, error) {
+		var zero R
+		va, err := inputAs[A](input, 0)
+		if err != nil {
+			return zero, err
+		}
+		return f(ctx, va)
+	}, typeOf[A]())
+	return &TypedNode[R]{Node: a.AddNextE(id, op)}
+}
+
+// AddTyped2 adds a typed node running f with the outputs of a and b
+func AddTyped2[A, B, R any](a *TypedNode[A], b *TypedNode[B], id string, f func(ctx context.Context, a A, b B) (R, error)) *TypedNode[R] {
+	op := newTypedOp[R](func(ctx context.Context, input []interface{}) (R, error) {
+		var zero R
+		va, err := inputAs[A](input, 0)
+		if err != nil {
+			return zero, err
+		}
+		vb, err := inputAs[B](input, 1)
+		if err != nil {
+			return zero, err
+		}
+		return f(ctx, va, vb)
+	}, typeOf[A](), typeOf[B]())
+	n := a.AddNextE(id, op)
+	b.AddNextNode(n)
+	return &TypedNode[R]{Node: n}
+}
+
+// AddTyped3 adds a typed node running f with the outputs of a, b and c
+func AddTyped3[A, B, C, R any](a *TypedNode[A], b *TypedNode[B], c *TypedNode[C], id string, f func(ctx context.Context, a A, b B, c C) (R, error)) *TypedNode[R] {
+	op := newTypedOp[R](func(ctx context.Context, input []interface{}) (R, error) {
+		var zero R
+		va, err := inputAs[A](input, 0)
+		if err != nil {
+			return zero, err
+		}
+		vb, err := inputAs[B](input, 1)
+		if err != nil {
+			return zero, err
+		}
+		vc, err := inputAs[C](input, 2)
+		if err != nil {
+			return zero, err
+		}
+		return f(ctx, va, vb, vc)
+	}, typeOf[A](), typeOf[B](), typeOf[C]())
+	n := a.AddNextE(id, op)
+	b.AddNextNode(n)
+	c.AddNextNode(n)
+	return &TypedNode[R]{Node: n}
+}
+
+// typedOp adapts a typed func to OpE and records its signature for Validate
+type typedOp struct {
+	in  []reflect.Type // type of each input, in the order of prev
+	out reflect.Type
+	fn  func(ctx context.Context, input []interface{}) (interface{}, error)
+}
+
+func newTypedOp[R any](fn func(ctx context.Context, input []interface{}) (R, error), in ...reflect.Type) *typedOp {
+	return &typedOp{
+		in:  in,
+		out: typeOf[R](),
+		fn: func(ctx context.Context, input []interface{}) (interface{}, error) {
+			output, err := fn(ctx, input)
+			if err != nil {
+				return nil, err
+			}
+			return output, nil
+		},
+	}
+}
+
+func (o *typedOp) ProcessE(ctx context.Context, global interface{}, input ...interface{}) (interface{}, error) {
+	if global != nil {
+		ctx = context.WithValue(ctx, StateKey(Global), global)
+	}
+	return o.fn(ctx, input)
+}
+
+// signature is shown as the op type in the export
+func (o *typedOp) signature() string {
+	in := make([]string, len(o.in))
+	for i, t := range o.in {
+		in[i] = t.String()
+	}
+	return fmt.Sprintf("func(%s) %s", strings.Join(in, ", "), o.out)
+}
+
+// inputAs converts input i to T, a nil input (e.g. from a failed parent
+// under ContinueAll) is the zero value of T
+func inputAs[T any](input []interface{}, i int) (T, error) {
+	var zero T
+	if i >= len(input) {
+		return zero, fmt.Errorf("godag: missing input %d of %s", i, typeOf[T]())
+	}
+	if input[i] == nil {
+		return zero, nil
+	}
+	v, ok := input[i].(T)
+	if !ok {
+		return zero, fmt.Errorf("godag: input %d is %T, want %s", i, input[i], typeOf[T]())
+	}
+	return v, nil
+}
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// typedOpOf returns the typedOp of node n, nil if the op is untyped
+func typedOpOf(n *Node) *typedOp {
+	if a, ok := n.op.(opEAdapter); ok {
+		if o, ok := a.OpE.(*typedOp); ok {
+			return o
+		}
+	}
+	return nil
+}
+
+// checkTypes returns the mismatches between the typed nodes and their parents
+func checkTypes(nodes []*Node) []string {
+	var mismatches []string
+	for _, n := range nodes {
+		op := typedOpOf(n)
+		if op == nil || len(op.in) == 0 {
+			continue
+		}
+		if len(n.prev) != len(op.in) {
+			mismatches = append(mismatches,
+				fmt.Sprintf("node %q has %d parent(s) but its op takes %d input(s)", n.id, len(n.prev), len(op.in)))
+			continue
+		}
+		for i, parent := range n.prev {
+			if parentOp := typedOpOf(parent); parentOp != nil && !parentOp.out.AssignableTo(op.in[i]) {
+				mismatches = append(mismatches,
+					fmt.Sprintf("input %d of node %q is %s but %q outputs %s", i, n.id, op.in[i], parent.id, parentOp.out))
+			}
+		}
+	}
+	return mismatches
+}
@@ -0,0 +1,104 @@
+package godag
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTyped(t *testing.T) {
+	/**
+	           |-> num(int) ---------------> |
+	    start->|                             |-> sum(string) -> op4
+	           |-> op2(untyped) -> len(int)->|
+	**/
+	start := NewStartNode("start")
+	num := AddTyped0(start, "num", func(ctx context.Context) (int, error) {
+		return 40, nil
+	})
+	op2 := Typed[string](start.AddNext("op2", &ConcatOp{name: "op2"}))
+	length := AddTyped1(op2, "len", func(ctx context.Context, s string) (int, error) {
+		return len(s), nil
+	})
+	sum := AddTyped2(num, length, "sum", func(ctx context.Context, a, b int) (string, error) {
+		return strconv.Itoa(a + b), nil
+	})
+	sum.AddNext("op4", &ConcatOp{name: "op4"})
+
+	sk := NewDefaultStateKeeper()
+	sk.SetGlobal("g")
+	var dag DAG
+	assert.NoError(t, dag.Init(start, sk))
+	assert.NoError(t, dag.Execute(context.Background()))
+	output, ok := sum.Output(dag.GetStateKeeper())
+	assert.True(t, ok)
+	assert.Equal(t, "51", output) // 40 + len("gop2[<nil>]")
+	assert.Equal(t, "gop4[51]", dag.GetStateKeeper().GetOutput("op4"))
+	_, ok = length.Output(dag.GetStateKeeper())
+	assert.True(t, ok)
+	assert.Equal(t, "func(int, int) string", opTypeName(sum.op))
+}
+
+func TestTypedGlobalAndError(t *testing.T) {
+	start := NewStartNode("start")
+	global := AddTyped0(start, "global", func(ctx context.Context) (string, error) {
+		return ctx.Value(StateKey(Global)).(string), nil
+	})
+	AddTyped1(global, "fail", func(ctx context.Context, s string) (int, error) {
+		return 0, errors.New("bad " + s)
+	})
+
+	sk := NewDefaultStateKeeper()
+	sk.SetGlobal("g")
+	var dag DAG
+	assert.NoError(t, dag.Init(start, sk))
+	assert.Error(t, dag.Execute(context.Background()))
+	assert.Equal(t, "g", dag.GetStateKeeper().GetOutput("global"))
+	assert.Equal(t, StatusFailed, dag.GetNodeStatus("fail"))
+	assert.Equal(t, "bad g", dag.GetReport().Node("fail").Error)
+	assert.Nil(t, dag.GetStateKeeper().GetOutput("fail"))
+}
+
+func TestTypedMismatch(t *testing.T) {
+	// an untyped parent declared with a wrong type fails the child at run time
+	start := NewStartNode("start")
+	op1 := Typed[int](start.AddNext("op1", &ConcatOp{name: "op1"}))
+	AddTyped1(op1, "double", func(ctx context.Context, n int) (int, error) {
+		return n * 2, nil
+	})
+
+	var dag DAG
+	assert.NoError(t, dag.Init(start, nil))
+	assert.Error(t, dag.Execute(context.Background()))
+	assert.Equal(t, "godag: input 0 is string, want int", dag.GetReport().Node("double").Error)
+
+	// typed nodes linked by hand are checked by Validate
+	start = NewStartNode("start")
+	str := AddTyped0(start, "str", func(ctx context.Context) (string, error) {
+		return "s", nil
+	})
+	num := AddTyped0(start, "num", func(ctx context.Context) (int, error) {
+		return 1, nil
+	})
+	double := AddTyped1(num, "double", func(ctx context.Context, n int) (int, error) {
+		return n * 2, nil
+	})
+	str.AddNextNode(double.Node)
+
+	err := Validate(start)
+	var ve *ValidationError
+	assert.True(t, errors.As(err, &ve))
+	assert.Equal(t, []string{`node "double" has 2 parent(s) but its op takes 1 input(s)`}, ve.TypeMismatch)
+
+	start = NewStartNode("start")
+	str = AddTyped0(start, "str", func(ctx context.Context) (string, error) {
+		return "s", nil
+	})
+	str.AddNextNode(NewNodeE("double", double.op.(opEAdapter).OpE))
+	err = Validate(start)
+	assert.True(t, errors.As(err, &ve))
+	assert.Equal(t, []string{`input 0 of node "double" is int but "str" outputs string`}, ve.TypeMismatch)
+}
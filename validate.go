@@ -0,0 +1,170 @@
+package godag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError describes why a graph cannot be executed
+type ValidationError struct {
+	Cycle          []string // ids along a cycle, the first id is repeated at the end
+	Duplicates     []string // ids used by more than one node
+	Unreachable    []string // ids of nodes which are not reachable from the start node
+	Inconsistent   []string // nodes whose indegree, prev and next do not agree
+	TypeMismatch   []string // typed nodes whose parents do not fit their inputs
+	InputConflicts []string // named inputs fed by more than one output, outputs feeding more than one input, or undeclared ports
+}
+
+func (e *ValidationError) Error() string {
+	var msgs []string
+	if len(e.Cycle) > 0 {
+		msgs = append(msgs, "cycle "+strings.Join(e.Cycle, " -> "))
+	}
+	if len(e.Duplicates) > 0 {
+		msgs = append(msgs, "duplicate ids "+strings.Join(e.Duplicates, ", "))
+	}
+	if len(e.Unreachable) > 0 {
+		msgs = append(msgs, "unreachable nodes "+strings.Join(e.Unreachable, ", "))
+	}
+	msgs = append(msgs, e.Inconsistent...)
+	msgs = append(msgs, e.TypeMismatch...)
+	msgs = append(msgs, e.InputConflicts...)
+	return "godag: invalid graph: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks the graph linked to startNode, which should have no cycle,
+// no duplicate id, no node unreachable from startNode and the indegree of each
+// node should be the number of its parents, which a quorum (see WithQuorum)
+// should not exceed. The output of a typed parent should fit the input of a
+// typed child (see AddTyped1), and each named input (see WithInput) should be
+// fed by one parent. The returned error is a *ValidationError.
+func Validate(startNode *Node) error {
+	// collect every node linked to startNode by either prev or next
+	nodes := []*Node{startNode}
+	seen := map[*Node]bool{startNode: true}
+	for i := 0; i < len(nodes); i++ {
+		for _, links := range [][]*Node{nodes[i].prev, nodes[i].next} {
+			for _, n := range links {
+				if !seen[n] {
+					seen[n] = true
+					nodes = append(nodes, n)
+				}
+			}
+		}
+	}
+
+	e := &ValidationError{}
+	ids := make(map[string]*Node)
+	for _, n := range nodes {
+		if other, ok := ids[n.id]; !ok {
+			ids[n.id] = n
+		} else if other != nil {
+			e.Duplicates = append(e.Duplicates, n.id)
+			ids[n.id] = nil // report once
+		}
+	}
+
+	reachable := map[*Node]bool{startNode: true}
+	queue := []*Node{startNode}
+	for i := 0; i < len(queue); i++ {
+		for _, child := range queue[i].next {
+			if !reachable[child] {
+				reachable[child] = true
+				queue = append(queue, child)
+			}
+		}
+	}
+	for _, n := range nodes {
+		if !reachable[n] {
+			e.Unreachable = append(e.Unreachable, n.id)
+		}
+	}
+
+	for _, n := range nodes {
+		if n.indegree != len(n.prev) {
+			e.Inconsistent = append(e.Inconsistent,
+				fmt.Sprintf("node %q has indegree %d but %d parent(s)", n.id, n.indegree, len(n.prev)))
+		}
+		if n.trigger == TriggerQuorum && (n.quorum <= 0 || n.quorum > len(n.prev)) {
+			e.Inconsistent = append(e.Inconsistent,
+				fmt.Sprintf("node %q has quorum %d but %d parent(s)", n.id, n.quorum, len(n.prev)))
+		}
+		for _, child := range n.next {
+			if !containsNode(child.prev, n) {
+				e.Inconsistent = append(e.Inconsistent,
+					fmt.Sprintf("node %q is a child of %q but not in its prev", child.id, n.id))
+			}
+		}
+		for _, parent := range n.prev {
+			if !containsNode(parent.next, n) {
+				e.Inconsistent = append(e.Inconsistent,
+					fmt.Sprintf("node %q is a parent of %q but not in its next", parent.id, n.id))
+			}
+		}
+	}
+
+	e.TypeMismatch = checkTypes(nodes)
+	e.InputConflicts = checkInputs(nodes)
+	e.Cycle = findCycle(nodes)
+
+	if len(e.Cycle) == 0 && len(e.Duplicates) == 0 && len(e.Unreachable) == 0 && len(e.Inconsistent) == 0 &&
+		len(e.TypeMismatch) == 0 && len(e.InputConflicts) == 0 {
+		return nil
+	}
+	return e
+}
+
+// findCycle returns the ids along the first cycle found by DFS, nil if no cycle
+func findCycle(nodes []*Node) []string {
+	const (
+		white = iota // not visited
+		gray         // on the DFS stack
+		black        // done
+	)
+	color := make(map[*Node]int)
+	var stack []*Node
+	var visit func(n *Node) []string
+	visit = func(n *Node) []string {
+		color[n] = gray
+		stack = append(stack, n)
+		for _, child := range n.next {
+			switch color[child] {
+			case gray:
+				var cycle []string
+				for i := len(stack) - 1; i >= 0; i-- {
+					if stack[i] == child {
+						for _, m := range stack[i:] {
+							cycle = append(cycle, m.id)
+						}
+						break
+					}
+				}
+				return append(cycle, child.id)
+			case white:
+				if cycle := visit(child); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		color[n] = black
+		return nil
+	}
+	for _, n := range nodes {
+		if color[n] == white {
+			if cycle := visit(n); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+func containsNode(nodes []*Node, node *Node) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
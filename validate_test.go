@@ -0,0 +1,48 @@
+package godag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	start := NewStartNode("start")
+	op1 := start.AddNext("op1", &ConcatOp{})
+	op2 := op1.AddNext("op2", &ConcatOp{})
+	op2.AddNext("op3", &ConcatOp{})
+	assert.NoError(t, Validate(start))
+
+	// op3 -> op1 closes the cycle op1 -> op2 -> op3 -> op1
+	cyclic := NewStartNode("start")
+	op1 = cyclic.AddNext("op1", &ConcatOp{})
+	op3 := op1.AddNext("op2", &ConcatOp{}).AddNext("op3", &ConcatOp{})
+	op3.AddNextNode(op1)
+	err := Validate(cyclic)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"op1", "op2", "op3", "op1"}, err.(*ValidationError).Cycle)
+	assert.Contains(t, err.Error(), "cycle op1 -> op2 -> op3 -> op1")
+
+	var dag DAG
+	assert.Equal(t, err, dag.Init(cyclic, nil))
+}
+
+func TestValidateNodes(t *testing.T) {
+	start := NewStartNode("start")
+	op1 := start.AddNext("op1", &ConcatOp{})
+	start.AddNext("op1", &ConcatOp{})
+	// orphan is a parent of op1 but not reachable from start
+	orphan := NewNode("orphan", &ConcatOp{})
+	op1.AddPrevNode(orphan)
+	// indegree does not match prev
+	op2 := op1.AddNext("op2", &ConcatOp{})
+	op2.indegree = 3
+
+	err := Validate(start)
+	assert.Error(t, err)
+	verr := err.(*ValidationError)
+	assert.Nil(t, verr.Cycle)
+	assert.Equal(t, []string{"op1"}, verr.Duplicates)
+	assert.Equal(t, []string{"orphan"}, verr.Unreachable)
+	assert.Equal(t, []string{`node "op2" has indegree 3 but 1 parent(s)`}, verr.Inconsistent)
+}